@@ -83,6 +83,26 @@ func GetFixtureEnlistmentFs() fstest.MapFS {
 				Item 1: 1 hour
 				`),
 		},
+		"directives": {
+			Data: []byte(
+				`#! scale: 1 year
+				#! reference: Item 2
+				#! units: 2
+				#! group: time
+				# Item X: 100 hours
+				Item 1: 1 hour
+				Item 2: 15 min
+				Item 3: 60 s
+				`),
+		},
+		"timeline": {
+			Data: []byte(
+				`Big Bang: 13.8e9 years
+				Earth forms: 4.54e9 years
+				Dinosaurs extinct: 66e6 years
+				Humans: 300000 years
+				`),
+		},
 	}
 }
 
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// FakeSQLDriver is the name of an in-memory database/sql driver for tests.
+// Every DSN is a single column table: "INSERT" appends the first argument
+// and "SELECT" returns all stored values in insertion order.
+const FakeSQLDriver = "refscaler-fake"
+
+var registerFakeSQL sync.Once
+
+// OpenFakeSQL opens a fresh in-memory table named dsn.
+func OpenFakeSQL(dsn string) (*sql.DB, error) {
+	registerFakeSQL.Do(func() {
+		sql.Register(
+			FakeSQLDriver,
+			&fakeDriver{tables: map[string]*fakeTable{}},
+		)
+	})
+
+	return sql.Open(FakeSQLDriver, dsn)
+}
+
+type fakeTable struct {
+	mu   sync.Mutex
+	rows []driver.Value
+}
+
+type fakeDriver struct {
+	mu     sync.Mutex
+	tables map[string]*fakeTable
+}
+
+func (d *fakeDriver) Open(dsn string) (driver.Conn, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	table, ok := d.tables[dsn]
+	if !ok {
+		table = &fakeTable{}
+		d.tables[dsn] = table
+	}
+
+	return &fakeConn{table: table}, nil
+}
+
+type fakeConn struct {
+	table *fakeTable
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	command := strings.ToUpper(strings.Fields(query + " ")[0])
+
+	switch command {
+	case "INSERT", "SELECT":
+		return &fakeStmt{table: c.table, command: command}, nil
+	default:
+		return nil, fmt.Errorf("unsupported query '%s'", query)
+	}
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("transactions are not supported")
+}
+
+type fakeStmt struct {
+	table   *fakeTable
+	command string
+}
+
+func (s *fakeStmt) Close() error {
+	return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+	if s.command == "INSERT" {
+		return 1
+	}
+
+	return 0
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if s.command != "INSERT" {
+		return nil, fmt.Errorf("cannot exec '%s'", s.command)
+	}
+
+	s.table.mu.Lock()
+	defer s.table.mu.Unlock()
+
+	s.table.rows = append(s.table.rows, args[0])
+
+	return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	if s.command != "SELECT" {
+		return nil, fmt.Errorf("cannot query '%s'", s.command)
+	}
+
+	s.table.mu.Lock()
+	defer s.table.mu.Unlock()
+
+	return &fakeRows{rows: append([]driver.Value(nil), s.table.rows...)}, nil
+}
+
+type fakeRows struct {
+	rows []driver.Value
+	next int
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"value"}
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.next >= len(r.rows) {
+		return io.EOF
+	}
+
+	dest[0] = r.rows[r.next]
+	r.next++
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+const usage = `Usage: refscaler <command> [flags] [arguments]
+
+Commands:
+  scale       scale an enlistment file to a new reference value
+  timeline    place the events of an enlistment file along a scaled span
+  units       inspect unit databases
+`
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var err error
+
+	switch args[0] {
+	case "scale":
+		err = runScale(args[1:], stdout, stderr)
+	case "timeline":
+		err = runTimeline(args[1:], stdout, stderr)
+	case "units":
+		err = runUnits(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "refscaler: unknown command '%s'\n\n", args[0])
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	if err != nil {
+		fmt.Fprintf(stderr, "refscaler: %s\n", err)
+
+		var unknown *units.UnknownAliasError
+		if errors.As(err, &unknown) && len(unknown.Hint()) > 0 {
+			fmt.Fprintf(stderr, "hint: %s\n", unknown.Hint())
+		}
+
+		return 1
+	}
+
+	return 0
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
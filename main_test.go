@@ -0,0 +1,573 @@
+package main
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+func helperWriteEnlistment(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "enlistment.txt")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func helperRun(t *testing.T, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+
+	// keep unit files of the user running the tests out of the registry
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	t.Setenv(units.UnitsPathEnv, "")
+
+	var out, errOut bytes.Buffer
+	code = run(args, &out, &errOut)
+
+	return code, out.String(), errOut.String()
+}
+
+func TestRunScaleDirectives(t *testing.T) {
+	path := helperWriteEnlistment(t, `#! scale: 1 year
+#! units: 3
+Item 1: 0.75 hour, 15 minutes
+Item 2: 15 minutes
+Item 3: 60 seconds
+`)
+
+	code, stdout, stderr := helperRun(t, "scale", path)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "Item 1: 1 year\n" +
+		"Item 2: 3 month, 1 day, 6.00 hour\n" +
+		"Item 3: 6 day, 2 hour\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunScaleFlagsOverrideDirectives(t *testing.T) {
+	path := helperWriteEnlistment(t, `#! scale: 1 year
+#! units: 3
+#! reference: Nope
+Item 1: 1 hour
+Item 2: 15 minutes
+`)
+
+	code, stdout, stderr := helperRun(
+		t,
+		"scale",
+		"-scale", "1 day",
+		"-reference", "Item 2",
+		"-units", "1",
+		path,
+	)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "Item 1: 4.00 day\nItem 2: 1.00 day\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunScaleAmbiguousScale(t *testing.T) {
+	path := helperWriteEnlistment(t, "A: 1 hour\nB: 30 minutes\n")
+
+	code, stdout, stderr := helperRun(t, "scale", "-scale", "100 m", path)
+	if code != 1 || len(stdout) > 0 {
+		t.Fatalf("expected exit code 1, got %d: %q", code, stdout)
+	}
+
+	if !strings.Contains(stderr, "'meter' (length) or 'minute' (time)") {
+		t.Fatalf("expected ambiguity error, got %q", stderr)
+	}
+
+	code, stdout, stderr = helperRun(
+		t,
+		"scale",
+		"-scale", "100 meters",
+		"-units", "1",
+		"-system", "metric",
+		path,
+	)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "A: 1.00 hectometer\nB: 50.00 meter\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunScaleMissingScale(t *testing.T) {
+	path := helperWriteEnlistment(t, "Item 1: 1 hour\n")
+
+	code, _, stderr := helperRun(t, "scale", path)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+
+	if !strings.Contains(stderr, "no scale given") {
+		t.Fatalf("expected missing scale error, got %q", stderr)
+	}
+}
+
+func TestRunScaleInvalidUnits(t *testing.T) {
+	path := helperWriteEnlistment(t, "Item 1: 1 hour\n")
+
+	for _, value := range []string{"0", "-2", "two"} {
+		code, _, stderr := helperRun(
+			t,
+			"scale",
+			"-scale", "1 day",
+			"-units", value,
+			path,
+		)
+		if code == 0 {
+			t.Errorf("-units %s: expected failure", value)
+		}
+
+		if !strings.Contains(stderr, "requires a positive integer") {
+			t.Errorf("-units %s: unexpected error %q", value, stderr)
+		}
+	}
+}
+
+func TestRunUnknownCommand(t *testing.T) {
+	if code, _, _ := helperRun(t, "unknown"); code != 2 {
+		t.Fatalf("expected exit code 2, got %d", code)
+	}
+}
+
+func TestRunScaleDocument(t *testing.T) {
+	path := helperWriteEnlistment(t, `#! scale: 1 day
+# Morning routine
+
+  Shower: 15 minutes
+Breakfast: 30 minutes
+`)
+
+	code, stdout, stderr := helperRun(t, "scale", "-document", path)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := `#! scale: 1 day
+# Morning routine
+
+  Shower: 12 hour
+Breakfast: 1 day
+`
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunScaleDocumentRejectsSort(t *testing.T) {
+	path := helperWriteEnlistment(t, "#! scale: 1 day\nItem 1: 1 hour\n")
+
+	code, _, stderr := helperRun(
+		t,
+		"scale",
+		"-document",
+		"-sort", "label",
+		path,
+	)
+	if code == 0 {
+		t.Fatal("expected failure")
+	}
+
+	if !strings.Contains(stderr, "-sort cannot be used with -document") {
+		t.Fatalf("unexpected error %q", stderr)
+	}
+}
+
+func TestRunScaleDocumentRejectsStats(t *testing.T) {
+	path := helperWriteEnlistment(t, "#! scale: 1 day\nItem 1: 1 hour\n")
+
+	code, _, stderr := helperRun(t, "scale", "-document", "-stats", path)
+	if code == 0 {
+		t.Fatal("expected failure")
+	}
+
+	if !strings.Contains(stderr, "-stats cannot be used with -document") {
+		t.Fatalf("unexpected error %q", stderr)
+	}
+}
+
+func TestRunScaleSortByInput(t *testing.T) {
+	path := helperWriteEnlistment(t, `Item 2: 15 minutes
+Item 1: 1 hour
+`)
+
+	code, stdout, stderr := helperRun(
+		t,
+		"scale",
+		"-scale", "4 hours",
+		"-sort", "input",
+		path,
+	)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "Item 2: 1 hour\nItem 1: 4 hour\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunScaleAcrossGroups(t *testing.T) {
+	path := helperWriteEnlistment(t, `#! scale: 100 meters
+Earth: 4.5e9 years
+Humans: 300000 years
+`)
+
+	code, stdout, stderr := helperRun(t, "scale", "-units", "1", path)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "Earth: 1.00 hectometer\nHumans: 6.67 millimeter\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunTimelineCalendar(t *testing.T) {
+	path := helperWriteEnlistment(t, `#! scale: 1 year
+Big Bang: 13.8e9 years
+Humans: 300000 years
+`)
+
+	code, stdout, stderr := helperRun(t, "timeline", "-calendar", path)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "Big Bang: January 1, 00:00\nHumans: December 31, 23:48\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunScalePiecewise(t *testing.T) {
+	path := helperWriteEnlistment(t, `Item 1: 1 hour
+Item 2: 15 minutes
+Item 3: 1 minute
+`)
+
+	code, stdout, stderr := helperRun(
+		t,
+		"scale",
+		"-scale", "1 day",
+		"-strategy", "piecewise",
+		"-anchor", "Item 3=1 hour",
+		"-units", "1",
+		path,
+	)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "Item 1: 1.00 day\nItem 2: 6.46 hour\nItem 3: 1.00 hour\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunScaleOutputUnits(t *testing.T) {
+	path := helperWriteEnlistment(t, `#! scale: 1 year
+Item 1: 1 hour
+Item 2: 15 minutes
+`)
+
+	testCases := []struct {
+		args     []string
+		expected string
+	}{
+		{
+			args:     []string{"-allow", "day, hour"},
+			expected: "Item 1: 365 day\nItem 2: 91 day, 6 hour\n",
+		},
+		{
+			args:     []string{"-allow", "week", "-remainder"},
+			expected: "Item 1: 52.14 week\nItem 2: 13.04 week\n",
+		},
+		{
+			args:     []string{"-allow", "week"},
+			expected: "Item 1: 52 week\nItem 2: 13 week\n",
+		},
+		{
+			args:     []string{"-unit", "weeks"},
+			expected: "Item 1: 52.14 week\nItem 2: 13.04 week\n",
+		},
+		{
+			args: []string{"-stats", "-unit", "weeks"},
+			expected: "Item 1: 52.14 week (rank 1, 100.00% of reference, " +
+				"80.00% of total, 80.00% cumulative)\n" +
+				"Item 2: 13.04 week (rank 2, 25.00% of reference, " +
+				"20.00% of total, 100.00% cumulative)\n" +
+				"count: 2\nsum: 65.18 week\nmean: 32.59 week\n" +
+				"median: 32.59 week\nmin: 13.04 week\nmax: 52.14 week\n" +
+				"geometric mean: 26.07 week\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		args := append([]string{"scale"}, tc.args...)
+
+		code, stdout, stderr := helperRun(t, append(args, path)...)
+		if code != 0 {
+			t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+		}
+
+		if stdout != tc.expected {
+			t.Fatalf("expected output %q, got %q", tc.expected, stdout)
+		}
+	}
+}
+
+func TestRunScaleBestFitRange(t *testing.T) {
+	path := helperWriteEnlistment(t, `Trip: 9 days
+Holiday: 5 days
+Day: 1 day
+`)
+
+	code, stdout, stderr := helperRun(
+		t,
+		"scale",
+		"-scale", "9 days",
+		"-best-fit",
+		"-fit-min", "10",
+		"-fit-max", "100",
+		path,
+	)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "Trip: 9 day\nHoliday: 120 hour\nDay: 24 hour\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+
+	code, _, stderr = helperRun(t, "scale", "-fit-min", "10", path)
+	if code == 0 || !strings.Contains(stderr, "require -best-fit") {
+		t.Fatalf("expected -best-fit error, got %d: %q", code, stderr)
+	}
+}
+
+func TestRunScaleExact(t *testing.T) {
+	path := helperWriteEnlistment(t, "A: 0.1 m\nB: 0.7 m\n")
+
+	code, stdout, stderr := helperRun(
+		t,
+		"scale",
+		"-exact",
+		"-reference", "A",
+		"-scale", "1 meter",
+		"-units", "5",
+		path,
+	)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	if expected := "B: 7 meter\nA: 1 meter\n"; stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunScaleDuplicates(t *testing.T) {
+	path := helperWriteEnlistment(t, `Item 1: 1 hour
+Item 2: 15 minutes
+Item 1: 1 hour
+`)
+
+	code, stdout, stderr := helperRun(
+		t,
+		"scale",
+		"-scale", "1 day",
+		"-units", "1",
+		path,
+	)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "Item 1: 1.00 day\nItem 1: 1.00 day\nItem 2: 6.00 hour\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+
+	code, _, stderr = helperRun(
+		t,
+		"scale",
+		"-scale", "1 day",
+		"-duplicates", "error",
+		path,
+	)
+	if code != 1 || !strings.Contains(stderr, "on lines 1 and 3") {
+		t.Fatalf("expected duplicate error, got %d: %s", code, stderr)
+	}
+
+	code, stdout, stderr = helperRun(
+		t,
+		"scale",
+		"-scale", "1 day",
+		"-duplicates", "sum",
+		"-units", "1",
+		path,
+	)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected = "Item 1: 1.00 day\nItem 2: 3.00 hour\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunUnitsCheck(t *testing.T) {
+	code, stdout, stderr := helperRun(t, "units", "check")
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	if !strings.HasSuffix(stdout, "0 errors, 2 warnings\n") {
+		t.Fatalf("unexpected report for built-in units: %q", stdout)
+	}
+
+	dir := t.TempDir()
+	err := os.WriteFile(
+		filepath.Join(dir, "length.json"),
+		[]byte(`[{"name": "kilometer", "value": 1000, "aliases": ["km"]}]`),
+		0o644,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, stdout, _ = helperRun(t, "units", "check", dir)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+
+	expected := "error: length: no base unit with multiplier 1\n" +
+		"1 errors, 0 warnings\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+
+	code, _, stderr = helperRun(t, "units", "check", "-units-dir", dir, dir)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+
+	if !strings.Contains(
+		stderr,
+		"-units-dir and -units-replace cannot be used with a units directory",
+	) {
+		t.Fatalf("unexpected error %q", stderr)
+	}
+
+	// broken user units are not loaded when checking a directory
+	broken := t.TempDir()
+	err = os.WriteFile(filepath.Join(broken, "time.json"), []byte(`[`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv(units.UnitsPathEnv, broken)
+
+	var out bytes.Buffer
+
+	code = run([]string{"units", "check", dir}, &out, io.Discard)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+
+	if out.String() != expected {
+		t.Fatalf("expected output %q, got %q", expected, out.String())
+	}
+}
+
+func TestRunScaleUnitsDir(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "effort.json"), []byte(`[
+		{"name": "point", "value": 1, "aliases": ["points", "sp"]},
+		{"name": "sprint", "value": 20, "aliases": ["sprints"]}
+	]`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := helperWriteEnlistment(t, "Login: 5 sp\nSearch: 1 sprint\n")
+
+	code, stdout, stderr := helperRun(
+		t,
+		"scale",
+		"-scale", "1 day",
+		"-units", "1",
+		"-units-dir", dir,
+		path,
+	)
+	if code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, stderr)
+	}
+
+	expected := "Search: 1.00 day\nLogin: 6.00 hour\n"
+
+	if stdout != expected {
+		t.Fatalf("expected output %q, got %q", expected, stdout)
+	}
+}
+
+func TestRunScaleUnknownAliasHint(t *testing.T) {
+	path := helperWriteEnlistment(t, "Item 1: 1 hour\nItem 2: 15 minuts\n")
+
+	code, _, stderr := helperRun(t, "scale", "-scale", "1 day", path)
+	if code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+
+	if !strings.HasSuffix(stderr, "hint: did you mean \"minute\"?\n") {
+		t.Fatalf("expected hint, got %q", stderr)
+	}
+
+	path = helperWriteEnlistment(t, "Item 1: 1 hour\nItem 2: 3 km\n")
+
+	_, _, stderr = helperRun(t, "scale", "-scale", "1 day", path)
+
+	hint := "hint: \"km\" is a unit of length, not time\n"
+	if !strings.HasSuffix(stderr, hint) {
+		t.Fatalf("expected hint listing other groups, got %q", stderr)
+	}
+}
@@ -0,0 +1,184 @@
+package refscaler
+
+import (
+	"fmt"
+	"math"
+	"slices"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+// RecordStats describes a record relative to the reference and to the rest of
+// the enlistment. Percentages range from 0 to 100.
+type RecordStats struct {
+	Label              string       `json:"label"`
+	Value              MeasureValue `json:"value"`
+	PercentOfReference float64      `json:"percent_of_reference"`
+	PercentOfTotal     float64      `json:"percent_of_total"`
+	CumulativePercent  float64      `json:"cumulative_percent"`
+	Rank               int          `json:"rank"`
+}
+
+// Summary holds statistics over all record values, in base units.
+type Summary struct {
+	Count         int          `json:"count"`
+	Sum           MeasureValue `json:"sum"`
+	Mean          MeasureValue `json:"mean"`
+	Median        MeasureValue `json:"median"`
+	Min           MeasureValue `json:"min"`
+	Max           MeasureValue `json:"max"`
+	GeometricMean MeasureValue `json:"geometric_mean"`
+}
+
+// Analysis is the relative view of an enlistment. Records keep the order of
+// the enlistment, ranks and cumulative percentages follow descending value.
+type Analysis struct {
+	Records []RecordStats `json:"records"`
+	Summary Summary       `json:"summary"`
+	group   *units.UnitGroup
+}
+
+func (r *RecordSlice) summarize() (summary Summary) {
+	summary.Count = len(*r)
+
+	if summary.Count == 0 {
+		return
+	}
+
+	values := make([]MeasureValue, 0, summary.Count)
+	logSum := 0.0
+
+	for _, rec := range *r {
+		values = append(values, rec.absValue)
+		summary.Sum += rec.absValue
+		logSum += math.Log(float64(rec.absValue))
+	}
+
+	slices.Sort(values)
+
+	summary.Min = values[0]
+	summary.Max = values[len(values)-1]
+	summary.Mean = summary.Sum / MeasureValue(summary.Count)
+	summary.GeometricMean = MeasureValue(
+		math.Exp(logSum / float64(summary.Count)),
+	)
+
+	if half := summary.Count / 2; summary.Count%2 == 1 {
+		summary.Median = values[half]
+	} else {
+		summary.Median = (values[half-1] + values[half]) / 2
+	}
+
+	return
+}
+
+// Analyze computes the relative view of every record together with summary
+// statistics. Percentages and the geometric mean are only defined for
+// positive values, so records of zero or negative value are an error.
+func (e *Enlistment) Analyze() (Analysis, error) {
+	for _, rec := range e.records {
+		if rec.absValue <= 0 {
+			return Analysis{}, fmt.Errorf(
+				"cannot analyze record '%s' with non-positive value %g",
+				rec.label,
+				rec.absValue,
+			)
+		}
+	}
+
+	summary := e.records.summarize()
+
+	ranked := slices.Clone(e.records)
+	slices.SortStableFunc(ranked, compareRecords(SortByValue))
+
+	stats := make(map[*Record]RecordStats, len(ranked))
+	cumulative := MeasureValue(0)
+
+	for i, rec := range ranked {
+		cumulative += rec.absValue
+
+		rank := i + 1
+		if i > 0 && ranked[i-1].absValue == rec.absValue {
+			rank = stats[ranked[i-1]].Rank
+		}
+
+		stats[rec] = RecordStats{
+			Label:              rec.label,
+			Value:              rec.absValue,
+			PercentOfReference: float64(rec.absValue/e.ref.absValue) * 100,
+			PercentOfTotal:     float64(rec.absValue/summary.Sum) * 100,
+			CumulativePercent:  float64(cumulative/summary.Sum) * 100,
+			Rank:               rank,
+		}
+	}
+
+	records := make([]RecordStats, 0, len(e.records))
+	for _, rec := range e.records {
+		records = append(records, stats[rec])
+	}
+
+	return Analysis{
+		Records: records,
+		Summary: summary,
+		group:   e.group,
+	}, nil
+}
+
+// ToString formats every record with its relative figures, followed by the
+// summary statistics.
+func (a *Analysis) ToString(num_units int) []string {
+	// only unit restrictions in FormatOptions can fail
+	result, _ := a.ToStringWith(FormatOptions{NumUnits: num_units})
+	return result
+}
+
+// ToStringWith is ToString using the units selected by opts.
+func (a *Analysis) ToStringWith(opts FormatOptions) ([]string, error) {
+	formatter, err := newMeasureFormatter(
+		opts,
+		a.group,
+		float64(a.Summary.Sum),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, 0, len(a.Records)+7)
+
+	for _, rec := range a.Records {
+		result = append(result, fmt.Sprintf(
+			"%s: %s (rank %d, %.2f%% of reference, %.2f%% of total, "+
+				"%.2f%% cumulative)",
+			rec.Label,
+			formatter.format(rec.Value),
+			rec.Rank,
+			rec.PercentOfReference,
+			rec.PercentOfTotal,
+			rec.CumulativePercent,
+		))
+	}
+
+	summary := []struct {
+		name  string
+		value MeasureValue
+	}{
+		{"sum", a.Summary.Sum},
+		{"mean", a.Summary.Mean},
+		{"median", a.Summary.Median},
+		{"min", a.Summary.Min},
+		{"max", a.Summary.Max},
+		{"geometric mean", a.Summary.GeometricMean},
+	}
+
+	result = append(result, fmt.Sprintf("count: %d", a.Summary.Count))
+
+	for _, item := range summary {
+		result = append(result, fmt.Sprintf(
+			"%s: %s",
+			item.name,
+			formatter.format(item.value),
+		))
+	}
+
+	return result, nil
+}
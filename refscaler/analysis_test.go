@@ -0,0 +1,126 @@
+package refscaler
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+func TestEnlistmentAnalyze(t *testing.T) {
+	enlistment, err := NewEnlistment(
+		strings.NewReader(`A: 40 minutes
+			B: 10 minutes
+			C: 40 minutes
+			D: 10 minutes
+			E: 20 minutes
+			`),
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := enlistment.SortBy(SortByInput); err != nil {
+		t.Fatal(err)
+	}
+
+	analysis, err := enlistment.Analyze()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []RecordStats{
+		{"A", 2400, 100, 100.0 / 3, 100.0 / 3, 1},
+		{"B", 600, 25, 100.0 / 12, 100.0 * 11 / 12, 4},
+		{"C", 2400, 100, 100.0 / 3, 200.0 / 3, 1},
+		{"D", 600, 25, 100.0 / 12, 100, 4},
+		{"E", 1200, 50, 100.0 / 6, 100.0 * 5 / 6, 3},
+	}
+
+	for exp, res := range internal.IterZip(expected, analysis.Records) {
+		if exp.Label != res.Label || exp.Value != res.Value ||
+			exp.Rank != res.Rank ||
+			!helperAlmostEqual(
+				MeasureValue(exp.PercentOfReference),
+				MeasureValue(res.PercentOfReference),
+			) ||
+			!helperAlmostEqual(
+				MeasureValue(exp.PercentOfTotal),
+				MeasureValue(res.PercentOfTotal),
+			) ||
+			!helperAlmostEqual(
+				MeasureValue(exp.CumulativePercent),
+				MeasureValue(res.CumulativePercent),
+			) {
+			t.Fatalf("expected %+v, got %+v", exp, res)
+		}
+	}
+
+	summary := analysis.Summary
+
+	if summary.Count != 5 || summary.Sum != 7200 || summary.Mean != 1440 ||
+		summary.Median != 1200 || summary.Min != 600 || summary.Max != 2400 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+
+	if !helperAlmostEqual(summary.GeometricMean, 1200) {
+		t.Fatalf("unexpected geometric mean %g", summary.GeometricMean)
+	}
+
+	lines := analysis.ToString(2)
+
+	if exp := "E: 20 minute (rank 3, 50.00% of reference, 16.67% of total, " +
+		"83.33% cumulative)"; lines[4] != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, lines[4])
+	}
+
+	if exp := "sum: 2 hour"; lines[6] != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, lines[6])
+	}
+
+	lines, err = analysis.ToStringWith(FormatOptions{
+		NumUnits: 2,
+		Unit:     "second",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := "sum: 7200.00 second"; lines[6] != exp {
+		t.Fatalf("expected '%s', got '%s'", exp, lines[6])
+	}
+
+	if _, err := analysis.ToStringWith(FormatOptions{
+		Allow: []string{"parsec"},
+	}); err == nil {
+		t.Fatal("expected error for unknown allowed unit")
+	}
+
+	data, err := json.Marshal(analysis)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(data), `"percent_of_reference":50`) {
+		t.Fatalf("expected JSON to contain relative figures, got %s", data)
+	}
+}
+
+func TestEnlistmentAnalyzeNonPositive(t *testing.T) {
+	enlistment, err := NewEnlistment(
+		strings.NewReader("a: 1 hour\nb: -2 hour\n"),
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = enlistment.Analyze()
+	wantErr := "cannot analyze record 'b' with non-positive value -7200"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("error = %v, want %q", err, wantErr)
+	}
+}
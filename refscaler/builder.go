@@ -0,0 +1,158 @@
+package refscaler
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+// ErrReadOnly is returned when modifying an enlistment built by
+// EnlistmentBuilder.
+var ErrReadOnly = errors.New("enlistment is read-only")
+
+// EnlistmentBuilder assembles an enlistment record by record without going
+// through text. Records are validated as they are added, the same way lines
+// of an enlistment file are, and Build returns a new read-only enlistment
+// that later changes to the builder do not affect. Scaling it returns a new
+// enlistment that can be modified.
+type EnlistmentBuilder struct {
+	registry   units.UnitRegistry
+	group      *units.UnitGroup
+	entries    []Entry
+	duplicates DuplicatePolicy
+}
+
+// NewEnlistmentBuilder returns an empty builder resolving unit groups from
+// registry.
+func NewEnlistmentBuilder(registry units.UnitRegistry) *EnlistmentBuilder {
+	return &EnlistmentBuilder{registry: registry}
+}
+
+// SetDuplicatePolicy selects how Build handles records sharing a label.
+func (b *EnlistmentBuilder) SetDuplicatePolicy(policy DuplicatePolicy) {
+	b.duplicates = policy
+}
+
+// Add appends a record with the value of quantity.
+func (b *EnlistmentBuilder) Add(label string, quantity units.Quantity) error {
+	if quantity.Unit == nil {
+		return fmt.Errorf("record '%s': quantity without a unit", label)
+	}
+
+	group := quantity.Unit.Group()
+	if b.group != nil && group != b.group {
+		return fmt.Errorf(
+			"record '%s': unit '%s' does not belong to group '%s'",
+			label,
+			quantity.Unit.Name,
+			b.group.Name(),
+		)
+	}
+
+	return b.add(label, quantity.String(), group)
+}
+
+// AddParsed appends a record with a measure such as "1 hour, 15 minutes".
+func (b *EnlistmentBuilder) AddParsed(label, measures string) error {
+	return b.add(label, measures, b.group)
+}
+
+func (b *EnlistmentBuilder) add(
+	label, measures string,
+	group *units.UnitGroup,
+) error {
+	// MarshalText and WriteDocument would write the record as a comment.
+	if strings.HasPrefix(label, "#") {
+		return fmt.Errorf("label '%s' starts with '#'", label)
+	}
+
+	line := fmt.Sprintf("%s: %s", label, measures)
+
+	entry, err := newEntry(line)
+	if err != nil {
+		return err
+	}
+
+	if entry.label != label {
+		return fmt.Errorf("label '%s' contains ': ' separator", label)
+	}
+
+	entry.raw = line
+
+	if group == nil {
+		draft := Enlistment{}
+		if err := draft.determineUnitGroup(entry, b.registry); err != nil {
+			return err
+		}
+		group = draft.group
+	}
+
+	if _, err := newRecord(entry, group); err != nil {
+		locateUnknownAlias(err, b.registry)
+		return fmt.Errorf("failed to add entry '%s': %w", entry.line, err)
+	}
+
+	b.group = group
+	b.entries = append(b.entries, entry)
+
+	return nil
+}
+
+// Remove deletes every record labelled label.
+func (b *EnlistmentBuilder) Remove(label string) error {
+	count := len(b.entries)
+
+	b.entries = slices.DeleteFunc(b.entries, func(entry Entry) bool {
+		return entry.label == label
+	})
+
+	if len(b.entries) == count {
+		return fmt.Errorf("label '%s' not found", label)
+	}
+
+	if len(b.entries) == 0 {
+		b.group = nil
+	}
+
+	return nil
+}
+
+// Len returns the number of records added so far.
+func (b *EnlistmentBuilder) Len() int {
+	return len(b.entries)
+}
+
+// Build returns an enlistment holding the records added so far, sorted by
+// value with the largest one as the reference.
+func (b *EnlistmentBuilder) Build() (*Enlistment, error) {
+	if len(b.entries) == 0 {
+		return nil, fmt.Errorf("enlistment is empty")
+	}
+
+	enlistment := NewEnlistmentDefault()
+	enlistment.group = b.group
+	enlistment.duplicates = b.duplicates
+	enlistment.indexed = true
+
+	for position, entry := range b.entries {
+		entry.position = position
+		enlistment.layout = append(enlistment.layout, entry)
+
+		if err := enlistment.addRecord(entry); err != nil {
+			return nil, fmt.Errorf(
+				"failed to add entry '%s': %w",
+				entry.line,
+				err,
+			)
+		}
+	}
+
+	enlistment.suffixDuplicates()
+	enlistment.sort()
+	enlistment.readOnly = true
+
+	return enlistment, nil
+}
@@ -0,0 +1,197 @@
+package refscaler
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+func TestEnlistmentBuilder(t *testing.T) {
+	group, _ := units.EmbeddedUnitRegistry.Get("time")
+	day, _ := group.Get("day")
+
+	builder := NewEnlistmentBuilder(units.EmbeddedUnitRegistry)
+
+	if err := builder.AddParsed("Lunch", "1 hour, 15 minutes"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := builder.Add("Trip", units.NewQuantity(2, day)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := builder.AddParsed("Coffee", "10 min"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := builder.Remove("Coffee"); err != nil {
+		t.Fatal(err)
+	}
+
+	enlistment, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []internal.TestEnlistment{
+		{Label: "Trip", Value: 172800},
+		{Label: "Lunch", Value: 4500},
+	}
+
+	if err := helperCompareEnlistments(expected, enlistment); err != nil {
+		t.Fatal(err)
+	}
+
+	if enlistment.ref.label != "Trip" {
+		t.Errorf("reference = %s, want Trip", enlistment.ref.label)
+	}
+
+	var buf bytes.Buffer
+	if err := enlistment.WriteDocument(&buf, 2); err != nil {
+		t.Fatal(err)
+	}
+
+	document := "Lunch: 1 hour, 15.00 minute\nTrip: 2 day\n"
+	if buf.String() != document {
+		t.Errorf("WriteDocument() = %q, want %q", buf.String(), document)
+	}
+
+	if err := builder.AddParsed("Nap", "20 min"); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(enlistment.records) != 2 {
+		t.Errorf(
+			"built enlistment changed to %d records",
+			len(enlistment.records),
+		)
+	}
+
+	if err := enlistment.SetReference("Lunch"); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SetReference() error = %v, want ErrReadOnly", err)
+	}
+
+	if err := enlistment.SortBy(SortByLabel); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("SortBy() error = %v, want ErrReadOnly", err)
+	}
+
+	err = enlistment.UnmarshalText([]byte("A: 1 hour\n"))
+	if !errors.Is(err, ErrReadOnly) {
+		t.Errorf("UnmarshalText() error = %v, want ErrReadOnly", err)
+	}
+
+	if enlistment.ref.label != "Trip" || enlistment.records[0].label != "Trip" {
+		t.Errorf("read-only enlistment was modified")
+	}
+
+	scaled := enlistment.GetScaled(enlistment.ref.absValue)
+	if err := scaled.SortBy(SortByLabel); err != nil {
+		t.Errorf("SortBy() on scaled enlistment: %v", err)
+	}
+}
+
+func TestEnlistmentBuilderErrors(t *testing.T) {
+	meter, _ := units.EmbeddedUnitRegistry.Find("meter")
+	length, _ := meter.Get("meter")
+
+	testCases := []struct {
+		name    string
+		build   func(b *EnlistmentBuilder) error
+		wantErr string
+	}{
+		{
+			name: "empty label",
+			build: func(b *EnlistmentBuilder) error {
+				return b.AddParsed("", "1 hour")
+			},
+			wantErr: "missing label",
+		},
+		{
+			name: "label with separator",
+			build: func(b *EnlistmentBuilder) error {
+				return b.AddParsed("a: b", "1 hour")
+			},
+			wantErr: "contains ': ' separator",
+		},
+		{
+			name: "comment label",
+			build: func(b *EnlistmentBuilder) error {
+				return b.AddParsed("# note", "1 hour")
+			},
+			wantErr: "label '# note' starts with '#'",
+		},
+		{
+			name: "directive label",
+			build: func(b *EnlistmentBuilder) error {
+				return b.Add("#! scale", units.NewQuantity(1, length))
+			},
+			wantErr: "label '#! scale' starts with '#'",
+		},
+		{
+			name: "unknown alias",
+			build: func(b *EnlistmentBuilder) error {
+				return b.AddParsed("A", "1 parsnip")
+			},
+			wantErr: "failed to determine unit group for alias 'parsnip'",
+		},
+		{
+			name: "mixed groups parsed",
+			build: func(b *EnlistmentBuilder) error {
+				if err := b.AddParsed("A", "1 hour"); err != nil {
+					return err
+				}
+
+				return b.AddParsed("B", "1 meter")
+			},
+			wantErr: "alias 'meter' not found",
+		},
+		{
+			name: "mixed groups quantity",
+			build: func(b *EnlistmentBuilder) error {
+				if err := b.AddParsed("A", "1 hour"); err != nil {
+					return err
+				}
+
+				return b.Add("B", units.NewQuantity(1, length))
+			},
+			wantErr: "does not belong to group 'time'",
+		},
+		{
+			name: "quantity without unit",
+			build: func(b *EnlistmentBuilder) error {
+				return b.Add("A", units.Quantity{})
+			},
+			wantErr: "quantity without a unit",
+		},
+		{
+			name: "remove missing",
+			build: func(b *EnlistmentBuilder) error {
+				return b.Remove("A")
+			},
+			wantErr: "label 'A' not found",
+		},
+		{
+			name: "build empty",
+			build: func(b *EnlistmentBuilder) error {
+				_, err := b.Build()
+				return err
+			},
+			wantErr: "enlistment is empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			builder := NewEnlistmentBuilder(units.EmbeddedUnitRegistry)
+
+			err := tc.build(builder)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package refscaler
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// SortOrder selects how records are ordered in the enlistment output.
+type SortOrder int
+
+const (
+	SortByValue SortOrder = iota // descending by value
+	SortByLabel                  // ascending by label
+	SortByInput                  // order of appearance in the source
+)
+
+var sortOrderNames = map[SortOrder]string{
+	SortByValue: "value",
+	SortByLabel: "label",
+	SortByInput: "input",
+}
+
+func (o SortOrder) String() string {
+	if name, ok := sortOrderNames[o]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("SortOrder(%d)", int(o))
+}
+
+// ParseSortOrder returns the SortOrder named "value", "label" or "input".
+func ParseSortOrder(name string) (SortOrder, error) {
+	for order, orderName := range sortOrderNames {
+		if orderName == name {
+			return order, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown sort order '%s'", name)
+}
+
+func compareRecords(order SortOrder) func(a, b *Record) int {
+	switch order {
+	case SortByLabel:
+		return func(a, b *Record) int {
+			return cmp.Or(
+				strings.Compare(a.label, b.label),
+				cmp.Compare(a.position, b.position),
+			)
+		}
+	case SortByInput:
+		return func(a, b *Record) int {
+			return cmp.Compare(a.position, b.position)
+		}
+	default:
+		return func(a, b *Record) int {
+			return cmp.Compare(b.absValue, a.absValue)
+		}
+	}
+}
+
+// SortBy reorders the records used by ToString. It fails with ErrReadOnly on
+// enlistments returned by EnlistmentBuilder.Build.
+func (e *Enlistment) SortBy(order SortOrder) error {
+	if e.readOnly {
+		return ErrReadOnly
+	}
+
+	slices.SortStableFunc(e.records, compareRecords(order))
+
+	return nil
+}
+
+// WriteDocument writes the enlistment in the layout of its source: comments,
+// directives and blank lines are copied verbatim and every record line is
+// replaced with its current value, keeping the original indentation.
+func (e *Enlistment) WriteDocument(w io.Writer, num_units int) error {
+	return e.WriteDocumentWith(w, FormatOptions{NumUnits: num_units})
+}
+
+func (e *Enlistment) writeDocument(
+	w io.Writer,
+	formatter *measureFormatter,
+) error {
+	byPosition := make(map[int]*Record, len(e.records))
+	for _, rec := range e.records {
+		byPosition[rec.position] = rec
+	}
+
+	writer := bufio.NewWriter(w)
+
+	for _, entry := range e.layout {
+		line := entry.raw
+
+		if rec, ok := byPosition[entry.position]; ok {
+			trimmed := strings.TrimLeft(entry.raw, " \t")
+			indent := entry.raw[:len(entry.raw)-len(trimmed)]
+			line = indent + rec.format(formatter)
+		}
+
+		if _, err := fmt.Fprintln(writer, line); err != nil {
+			return err
+		}
+	}
+
+	return writer.Flush()
+}
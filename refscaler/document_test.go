@@ -0,0 +1,93 @@
+package refscaler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+func TestEnlistmentSortBy(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"unsorted",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		order    SortOrder
+		expected []string
+	}{
+		{SortByInput, []string{"Item 2", "Item 3", "Item 1"}},
+		{SortByLabel, []string{"Item 1", "Item 2", "Item 3"}},
+		{SortByValue, []string{"Item 1", "Item 2", "Item 3"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.order.String(), func(t *testing.T) {
+			if err := enlistment.SortBy(tc.order); err != nil {
+				t.Fatal(err)
+			}
+
+			for exp, rec := range internal.IterZip(
+				tc.expected,
+				enlistment.records,
+			) {
+				if rec.label != exp {
+					t.Fatalf("expected label '%s', got '%s'", exp, rec.label)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSortOrder(t *testing.T) {
+	for _, order := range []SortOrder{SortByValue, SortByLabel, SortByInput} {
+		parsed, err := ParseSortOrder(order.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if parsed != order {
+			t.Fatalf("expected %s, got %s", order, parsed)
+		}
+	}
+
+	if _, err := ParseSortOrder("size"); err == nil {
+		t.Fatal("expected error for unknown sort order")
+	}
+}
+
+func TestEnlistmentWriteDocument(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"standard",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scale, _ := enlistment.MakeMeasureValue("1 year")
+
+	var builder strings.Builder
+
+	scaled := enlistment.GetScaled(scale)
+	if err := scaled.WriteDocument(&builder, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Item 1: 1 year\n" +
+		"\t\t\t\t# Item X: 100 hours\n" +
+		"\t\t\t\tItem 2: 3 month, 1 day, 6.00 hour\n" +
+		"\t\t\t\tItem 3: 6 day, 2 hour\n" +
+		"\t\t\t\t\n"
+
+	if result := builder.String(); result != expected {
+		t.Fatalf("expected document %q, got %q", expected, result)
+	}
+}
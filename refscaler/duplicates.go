@@ -0,0 +1,160 @@
+package refscaler
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+)
+
+// DuplicatePolicy selects what happens when a record reuses a label. The zero
+// value keeps every record.
+type DuplicatePolicy int
+
+const (
+	DuplicateKeep      DuplicatePolicy = iota // keep both records
+	DuplicateError                            // fail naming both records
+	DuplicateLastWins                         // keep the later value
+	DuplicateFirstWins                        // keep the earlier value
+	DuplicateSum                              // add the values together
+	DuplicateSuffix                           // rename to "label (2)", ...
+)
+
+var duplicatePolicyNames = map[DuplicatePolicy]string{
+	DuplicateKeep:      "keep",
+	DuplicateError:     "error",
+	DuplicateLastWins:  "last",
+	DuplicateFirstWins: "first",
+	DuplicateSum:       "sum",
+	DuplicateSuffix:    "suffix",
+}
+
+func (p DuplicatePolicy) String() string {
+	if name, ok := duplicatePolicyNames[p]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("DuplicatePolicy(%d)", int(p))
+}
+
+// ParseDuplicatePolicy returns the DuplicatePolicy named "keep", "error",
+// "last", "first", "sum" or "suffix".
+func ParseDuplicatePolicy(name string) (DuplicatePolicy, error) {
+	for policy, policyName := range duplicatePolicyNames {
+		if policyName == name {
+			return policy, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown duplicate policy '%s'", name)
+}
+
+func (e *Enlistment) findRecord(label string) *Record {
+	for _, rec := range e.records {
+		if rec.label == label {
+			return rec
+		}
+	}
+
+	return nil
+}
+
+// mergeDuplicate applies the duplicate policy to record whose label is
+// already taken by existing. It reports whether record still has to be
+// appended, possibly under a new label.
+func (e *Enlistment) mergeDuplicate(
+	existing, record *Record,
+) (bool, error) {
+	switch e.duplicates {
+	case DuplicateKeep:
+		return true, nil
+	case DuplicateSuffix:
+		// renamed by suffixDuplicates once every label is known
+		return true, nil
+	case DuplicateError:
+		// Records decoded from JSON or added to a builder have no lines.
+		if e.indexed {
+			return false, fmt.Errorf(
+				"duplicate label '%s' in records %d and %d",
+				record.label,
+				existing.position,
+				record.position,
+			)
+		}
+
+		return false, fmt.Errorf(
+			"duplicate label '%s' on lines %d and %d",
+			record.label,
+			existing.position+1,
+			record.position+1,
+		)
+	case DuplicateFirstWins:
+		e.dropLayout(record.position)
+	case DuplicateLastWins:
+		e.dropLayout(existing.position)
+		existing.absValue = record.absValue
+		existing.exact = record.exact
+		existing.position = record.position
+	case DuplicateSum:
+		e.dropLayout(record.position)
+		existing.absValue += record.absValue
+		if existing.exact != nil && record.exact != nil {
+			existing.exact = new(big.Rat).Add(existing.exact, record.exact)
+		}
+	default:
+		return false, fmt.Errorf("unknown duplicate policy %d", e.duplicates)
+	}
+
+	e.updateReference()
+
+	return false, nil
+}
+
+// suffixDuplicates renames repeated labels to the first "label (n)" not used
+// by any record, so labels appearing later in the input are never taken:
+// "a", "a", "a (2)" become "a", "a (3)", "a (2)". Records must still be in
+// input order.
+func (e *Enlistment) suffixDuplicates() {
+	if e.duplicates != DuplicateSuffix {
+		return
+	}
+
+	taken := make(map[string]bool, len(e.records))
+	for _, rec := range e.records {
+		taken[rec.label] = true
+	}
+
+	seen := make(map[string]bool, len(e.records))
+
+	for _, rec := range e.records {
+		if !seen[rec.label] {
+			seen[rec.label] = true
+			continue
+		}
+
+		label := rec.label
+		for n := 2; taken[rec.label]; n++ {
+			rec.label = fmt.Sprintf("%s (%d)", label, n)
+		}
+
+		taken[rec.label] = true
+		seen[rec.label] = true
+	}
+}
+
+// dropLayout removes the line of a merged record so documents do not repeat
+// it verbatim.
+func (e *Enlistment) dropLayout(position int) {
+	e.layout = slices.DeleteFunc(e.layout, func(entry Entry) bool {
+		return entry.kind == entryRecord && entry.position == position
+	})
+}
+
+func (e *Enlistment) updateReference() {
+	e.ref = nil
+
+	for _, rec := range e.records {
+		if e.ref == nil || e.ref.absValue < rec.absValue {
+			e.ref = rec
+		}
+	}
+}
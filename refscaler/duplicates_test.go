@@ -0,0 +1,234 @@
+package refscaler
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+const duplicateEnlistment = `# breaks
+Coffee: 10 minutes
+Lunch: 1 hour
+Coffee: 20 minutes
+`
+
+func TestNewEnlistmentDuplicates(t *testing.T) {
+	testCases := []struct {
+		policy   DuplicatePolicy
+		expected []internal.TestEnlistment
+		document string
+	}{
+		{
+			policy: DuplicateKeep,
+			expected: []internal.TestEnlistment{
+				{Label: "Lunch", Value: 3600},
+				{Label: "Coffee", Value: 1200},
+				{Label: "Coffee", Value: 600},
+			},
+			document: "# breaks\nCoffee: 10.00 minute\nLunch: 1.00 hour\n" +
+				"Coffee: 20.00 minute\n",
+		},
+		{
+			policy: DuplicateFirstWins,
+			expected: []internal.TestEnlistment{
+				{Label: "Lunch", Value: 3600},
+				{Label: "Coffee", Value: 600},
+			},
+			document: "# breaks\nCoffee: 10.00 minute\nLunch: 1.00 hour\n",
+		},
+		{
+			policy: DuplicateLastWins,
+			expected: []internal.TestEnlistment{
+				{Label: "Lunch", Value: 3600},
+				{Label: "Coffee", Value: 1200},
+			},
+			document: "# breaks\nLunch: 1.00 hour\nCoffee: 20.00 minute\n",
+		},
+		{
+			policy: DuplicateSum,
+			expected: []internal.TestEnlistment{
+				{Label: "Lunch", Value: 3600},
+				{Label: "Coffee", Value: 1800},
+			},
+			document: "# breaks\nCoffee: 30.00 minute\nLunch: 1.00 hour\n",
+		},
+		{
+			policy: DuplicateSuffix,
+			expected: []internal.TestEnlistment{
+				{Label: "Lunch", Value: 3600},
+				{Label: "Coffee (2)", Value: 1200},
+				{Label: "Coffee", Value: 600},
+			},
+			document: "# breaks\nCoffee: 10.00 minute\nLunch: 1.00 hour\n" +
+				"Coffee (2): 20.00 minute\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.policy.String(), func(t *testing.T) {
+			enlistment, err := NewEnlistmentWith(
+				strings.NewReader(duplicateEnlistment),
+				units.EmbeddedUnitRegistry,
+				LoadOptions{Duplicates: tc.policy},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := helperCompareEnlistments(
+				tc.expected,
+				enlistment,
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			var buf bytes.Buffer
+			if err := enlistment.WriteDocument(&buf, 1); err != nil {
+				t.Fatal(err)
+			}
+
+			if buf.String() != tc.document {
+				t.Errorf("document = %q, want %q", buf.String(), tc.document)
+			}
+		})
+	}
+}
+
+func TestNewEnlistmentDuplicateError(t *testing.T) {
+	_, err := NewEnlistmentWith(
+		strings.NewReader(duplicateEnlistment),
+		units.EmbeddedUnitRegistry,
+		LoadOptions{Duplicates: DuplicateError},
+	)
+
+	wantErr := "duplicate label 'Coffee' on lines 2 and 4"
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Errorf("error = %v, want %q", err, wantErr)
+	}
+}
+
+func TestDuplicateSuffixSkipsLaterLabels(t *testing.T) {
+	enlistment, err := NewEnlistmentWith(
+		strings.NewReader("a: 3 hours\na: 2 hours\na (2): 1 hour\n"),
+		units.EmbeddedUnitRegistry,
+		LoadOptions{Duplicates: DuplicateSuffix},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []internal.TestEnlistment{
+		{Label: "a", Value: 10800},
+		{Label: "a (3)", Value: 7200},
+		{Label: "a (2)", Value: 3600},
+	}
+	if err := helperCompareEnlistments(expected, enlistment); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDuplicateReferenceUpdated(t *testing.T) {
+	enlistment, err := NewEnlistmentWith(
+		strings.NewReader("A: 2 hours\nB: 1 hour\nA: 30 minutes\n"),
+		units.EmbeddedUnitRegistry,
+		LoadOptions{Duplicates: DuplicateLastWins},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if enlistment.ref.label != "B" {
+		t.Errorf("reference = %s, want B", enlistment.ref.label)
+	}
+}
+
+func TestUnmarshalJSONDuplicates(t *testing.T) {
+	data := []byte(`{"records": [
+		{"label": "Coffee", "value": "10 minutes"},
+		{"label": "Coffee", "value": "20 minutes"}
+	]}`)
+
+	var enlistment Enlistment
+
+	if err := json.Unmarshal(data, &enlistment); err != nil {
+		t.Fatal(err)
+	}
+
+	if enlistment.Length() != 2 {
+		t.Errorf("Length() = %d, want both records kept", enlistment.Length())
+	}
+
+	defer SetDecodeDuplicates(DuplicateKeep)
+
+	SetDecodeDuplicates(DuplicateError)
+
+	err := json.Unmarshal(data, &enlistment)
+	if err == nil || !strings.Contains(err.Error(), "in records 0 and 1") {
+		t.Errorf("error = %v, want duplicate in records 0 and 1", err)
+	}
+
+	SetDecodeDuplicates(DuplicateSum)
+
+	if err := json.Unmarshal(data, &enlistment); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []internal.TestEnlistment{{Label: "Coffee", Value: 1800}}
+	if err := helperCompareEnlistments(expected, &enlistment); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnlistmentBuilderDuplicates(t *testing.T) {
+	builder := NewEnlistmentBuilder(units.EmbeddedUnitRegistry)
+
+	for _, measure := range []string{"10 minutes", "20 minutes"} {
+		if err := builder.AddParsed("Coffee", measure); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	enlistment, err := builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if enlistment.Length() != 2 {
+		t.Errorf("Length() = %d, want both records kept", enlistment.Length())
+	}
+
+	builder.SetDuplicatePolicy(DuplicateError)
+
+	if _, err := builder.Build(); err == nil {
+		t.Error("Build() with duplicate labels expected error")
+	}
+
+	builder.SetDuplicatePolicy(DuplicateFirstWins)
+
+	enlistment, err = builder.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []internal.TestEnlistment{{Label: "Coffee", Value: 600}}
+	if err := helperCompareEnlistments(expected, enlistment); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseDuplicatePolicy(t *testing.T) {
+	for policy := range duplicatePolicyNames {
+		parsed, err := ParseDuplicatePolicy(policy.String())
+		if err != nil || parsed != policy {
+			t.Errorf("ParseDuplicatePolicy(%s) = %v, %v", policy, parsed, err)
+		}
+	}
+
+	if _, err := ParseDuplicatePolicy("merge"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
@@ -0,0 +1,461 @@
+package refscaler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+func (m MeasureValue) String() string {
+	return strconv.FormatFloat(float64(m), 'g', -1, 64)
+}
+
+// Format implements fmt.Formatter. "%v" and "%s" print String, the floating
+// point verbs format the value in base units, e.g. "%.2f".
+func (m MeasureValue) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's':
+		fmt.Fprintf(f, fmt.FormatString(f, 's'), m.String())
+	case 'b', 'e', 'E', 'f', 'F', 'g', 'G', 'x', 'X':
+		fmt.Fprintf(f, fmt.FormatString(f, verb), float64(m))
+	default:
+		fmt.Fprintf(f, "%%!%c(refscaler.MeasureValue=%s)", verb, m.String())
+	}
+}
+
+// MarshalText writes the value in base units.
+func (m MeasureValue) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText accepts a number in base units or a measure such as
+// "1 hour, 30 minutes" whose unit group is resolved from
+// units.DecodingRegistry.
+func (m *MeasureValue) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+
+	if value, err := strconv.ParseFloat(str, 64); err == nil {
+		*m = MeasureValue(value)
+		return nil
+	}
+
+	quantity, err := units.ParseQuantity(str, units.DecodingRegistry())
+	if err != nil {
+		return fmt.Errorf(
+			"failed to create measure value from '%s': %w",
+			str,
+			err,
+		)
+	}
+
+	*m = MeasureValue(quantity.Base())
+
+	return nil
+}
+
+func (m MeasureValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(float64(m))
+}
+
+// UnmarshalJSON accepts a JSON number in base units or a JSON string handled
+// by UnmarshalText.
+func (m *MeasureValue) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+
+		return m.UnmarshalText([]byte(text))
+	}
+
+	var value float64
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*m = MeasureValue(value)
+
+	return nil
+}
+
+type recordJSON struct {
+	Label string          `json:"label"`
+	Value json.RawMessage `json:"value"`
+	Text  string          `json:"text,omitempty"`
+}
+
+// String decomposes the value into up to units.DefaultNumUnits units of the
+// record's group, as Enlistment.String does for every record.
+func (r Record) String() string {
+	if r.group == nil {
+		return fmt.Sprintf("%s: %s", r.label, r.absValue)
+	}
+
+	return fmt.Sprintf(
+		"%s: %s",
+		r.label,
+		r.absValue.toString(
+			units.DefaultNumUnits,
+			r.group.UnitsUpTo(math.Abs(float64(r.absValue))),
+		),
+	)
+}
+
+// Format implements fmt.Formatter. "%+v" adds the source line of the record,
+// or its index for records decoded from JSON or added to a builder.
+func (r Record) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+') && r.indexed:
+		fmt.Fprintf(f, "%s (record %d)", r.String(), r.position)
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(f, "%s (line %d)", r.String(), r.position+1)
+	case verb == 'v' || verb == 's':
+		fmt.Fprint(f, r.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(refscaler.Record=%s)", verb, r.String())
+	}
+}
+
+func (r Record) MarshalJSON() ([]byte, error) {
+	value, err := r.absValue.MarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(recordJSON{Label: r.label, Value: value})
+}
+
+// UnmarshalJSON reads the label and a value that is a number in base units or
+// a measure string. A measure string also sets the unit group of the record.
+func (r *Record) UnmarshalJSON(data []byte) error {
+	var raw recordJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if len(raw.Label) == 0 {
+		return fmt.Errorf("record missing label")
+	}
+
+	var measures string
+	if err := json.Unmarshal(raw.Value, &measures); err == nil {
+		return r.decode(raw.Label, measures)
+	}
+
+	var value MeasureValue
+	if err := value.UnmarshalJSON(raw.Value); err != nil {
+		return fmt.Errorf("record '%s': %w", raw.Label, err)
+	}
+
+	*r = Record{label: raw.Label, absValue: value}
+
+	return nil
+}
+
+// MarshalText writes the record as a line of the text format with its value
+// in the base unit of its group, or as a plain number without a group.
+func (r Record) MarshalText() ([]byte, error) {
+	if r.group == nil {
+		return []byte(fmt.Sprintf("%s: %s", r.label, r.absValue)), nil
+	}
+
+	base, ok := r.group.Base()
+	if !ok {
+		return nil, fmt.Errorf(
+			"unit group '%s' has no base unit",
+			r.group.Name(),
+		)
+	}
+
+	return []byte(
+		fmt.Sprintf("%s: %s %s", r.label, r.absValue, base.Name),
+	), nil
+}
+
+// UnmarshalText reads a line such as "Task: 1 hour, 30 minutes" resolving the
+// unit group from units.DecodingRegistry, or "Task: 5400" in base units.
+func (r *Record) UnmarshalText(text []byte) error {
+	entry, err := newEntry(string(text))
+	if err != nil {
+		return err
+	}
+
+	return r.decode(entry.label, entry.measures)
+}
+
+func (r *Record) decode(label, measures string) error {
+	measures = strings.TrimSpace(measures)
+
+	if value, err := strconv.ParseFloat(measures, 64); err == nil {
+		*r = Record{label: label, absValue: MeasureValue(value)}
+		return nil
+	}
+
+	quantity, err := units.ParseQuantity(measures, units.DecodingRegistry())
+	if err != nil {
+		return fmt.Errorf("record '%s': %w", label, err)
+	}
+
+	*r = Record{
+		label:    label,
+		absValue: MeasureValue(quantity.Base()),
+		group:    quantity.Unit.Group(),
+	}
+
+	return nil
+}
+
+type enlistmentJSON struct {
+	Group     string       `json:"group,omitempty"`
+	Reference string       `json:"reference,omitempty"`
+	Records   []recordJSON `json:"records"`
+}
+
+func (e *Enlistment) String() string {
+	return strings.Join(e.ToString(units.DefaultNumUnits), "\n")
+}
+
+// Format implements fmt.Formatter. "%v" prints the records decomposed into
+// units, "%+v" starts with the unit group and the reference.
+func (e *Enlistment) Format(f fmt.State, verb rune) {
+	if err := e.checkLoaded(); err != nil {
+		fmt.Fprintf(f, "%%!%c(*refscaler.Enlistment=%s)", verb, err)
+		return
+	}
+
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprintf(
+			f,
+			"group: %s, reference: %s\n%s",
+			e.group.Name(),
+			e.ref.label,
+			e.String(),
+		)
+	case verb == 'v' || verb == 's':
+		fmt.Fprint(f, e.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(*refscaler.Enlistment)", verb)
+	}
+}
+
+// MarshalText writes the enlistment in the text format read by NewEnlistment
+// keeping its layout. Every value is written in the base unit of the group
+// with all its digits, so UnmarshalText restores it exactly.
+func (e *Enlistment) MarshalText() ([]byte, error) {
+	if err := e.checkLoaded(); err != nil {
+		return nil, err
+	}
+
+	base, ok := e.group.Base()
+	if !ok {
+		return nil, fmt.Errorf(
+			"unit group '%s' has no base unit",
+			e.group.Name(),
+		)
+	}
+
+	var buffer bytes.Buffer
+
+	if err := e.writeDocument(
+		&buffer,
+		&measureFormatter{base: base},
+	); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+var decodeDuplicates struct {
+	sync.RWMutex
+	policy DuplicatePolicy
+}
+
+// SetDecodeDuplicates sets the duplicate label policy used by UnmarshalText
+// and UnmarshalJSON of Enlistment. It is safe for concurrent use.
+func SetDecodeDuplicates(policy DuplicatePolicy) {
+	decodeDuplicates.Lock()
+	defer decodeDuplicates.Unlock()
+
+	decodeDuplicates.policy = policy
+}
+
+// DecodeDuplicates returns the policy set with SetDecodeDuplicates,
+// DuplicateKeep by default.
+func DecodeDuplicates() DuplicatePolicy {
+	decodeDuplicates.RLock()
+	defer decodeDuplicates.RUnlock()
+
+	return decodeDuplicates.policy
+}
+
+// UnmarshalText parses the text format with units.DecodingRegistry.
+func (e *Enlistment) UnmarshalText(text []byte) error {
+	if e.readOnly {
+		return ErrReadOnly
+	}
+
+	enlistment, err := NewEnlistmentWith(
+		bytes.NewReader(text),
+		units.DecodingRegistry(),
+		LoadOptions{Duplicates: DecodeDuplicates()},
+	)
+	if err != nil {
+		return err
+	}
+
+	*e = *enlistment
+
+	return nil
+}
+
+// MarshalJSON writes every record with its value in base units and its
+// decomposition into units of the group.
+func (e *Enlistment) MarshalJSON() ([]byte, error) {
+	formatter, err := e.newMeasureFormatter(
+		FormatOptions{NumUnits: units.DefaultNumUnits},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]recordJSON, 0, len(e.records))
+
+	for _, rec := range e.records {
+		value, err := rec.absValue.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+
+		records = append(records, recordJSON{
+			Label: rec.label,
+			Value: value,
+			Text:  formatter.format(rec.absValue),
+		})
+	}
+
+	return json.Marshal(enlistmentJSON{
+		Group:     e.group.Name(),
+		Reference: e.ref.label,
+		Records:   records,
+	})
+}
+
+// UnmarshalJSON reads records whose values are numbers in base units or
+// measure strings. The unit group is taken from "group" or resolved from
+// units.DecodingRegistry by the first measure string.
+func (e *Enlistment) UnmarshalJSON(data []byte) error {
+	if e.readOnly {
+		return ErrReadOnly
+	}
+
+	var raw enlistmentJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	registry := units.DecodingRegistry()
+	decoded := NewEnlistmentDefault()
+	decoded.duplicates = DecodeDuplicates()
+	decoded.indexed = true
+
+	if len(raw.Group) > 0 {
+		group, ok := registry.Get(raw.Group)
+		if !ok {
+			return fmt.Errorf("unit group '%s' not found", raw.Group)
+		}
+
+		decoded.group = group
+	}
+
+	for position, rec := range raw.Records {
+		if err := decoded.addJSONRecord(rec, position, registry); err != nil {
+			return err
+		}
+	}
+
+	if len(decoded.records) == 0 {
+		return fmt.Errorf("enlistment is empty")
+	}
+
+	if decoded.group == nil {
+		return fmt.Errorf("enlistment unit group cannot be determined")
+	}
+
+	decoded.suffixDuplicates()
+	decoded.sort()
+
+	if len(raw.Reference) > 0 {
+		if err := decoded.SetReference(raw.Reference); err != nil {
+			return err
+		}
+	}
+
+	*e = *decoded
+
+	return nil
+}
+
+func (e *Enlistment) addJSONRecord(
+	raw recordJSON,
+	position int,
+	registry units.UnitRegistry,
+) error {
+	var measures string
+
+	if err := json.Unmarshal(raw.Value, &measures); err != nil {
+		var value MeasureValue
+		if err := value.UnmarshalJSON(raw.Value); err != nil {
+			return fmt.Errorf("record '%s': %w", raw.Label, err)
+		}
+
+		if e.group == nil {
+			return fmt.Errorf("enlistment unit group cannot be determined")
+		}
+
+		base, ok := e.group.Base()
+		if !ok {
+			return fmt.Errorf(
+				"unit group '%s' has no base unit",
+				e.group.Name(),
+			)
+		}
+
+		measures = fmt.Sprintf("%s %s", value, base.Name)
+	}
+
+	entry := Entry{
+		label:    raw.Label,
+		measures: measures,
+		line:     fmt.Sprintf("%s: %s", raw.Label, measures),
+		position: position,
+	}
+	entry.raw = entry.line
+
+	if len(entry.label) == 0 {
+		return fmt.Errorf("record %d missing label", position)
+	}
+
+	if e.group == nil {
+		if err := e.determineUnitGroup(entry, registry); err != nil {
+			return err
+		}
+	}
+
+	e.layout = append(e.layout, entry)
+
+	if err := e.addRecord(entry); err != nil {
+		locateUnknownAlias(err, registry)
+		return fmt.Errorf("failed to add record '%s': %w", entry.line, err)
+	}
+
+	return nil
+}
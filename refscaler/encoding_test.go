@@ -0,0 +1,320 @@
+package refscaler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+func TestMeasureValueUnmarshalJSON(t *testing.T) {
+	var config struct {
+		Timeout  MeasureValue `json:"timeout"`
+		Distance MeasureValue `json:"distance"`
+		Raw      MeasureValue `json:"raw"`
+	}
+
+	if err := json.Unmarshal([]byte(`{
+		"timeout": "1 hour, 30 minutes",
+		"distance": "2 km",
+		"raw": 42.5
+	}`), &config); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Timeout != 5400 || config.Distance != 2000 || config.Raw != 42.5 {
+		t.Fatalf("unexpected config %+v", config)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := `{"timeout":5400,"distance":2000,"raw":42.5}`; string(
+		data,
+	) != exp {
+		t.Fatalf("expected %s, got %s", exp, data)
+	}
+
+	var invalid MeasureValue
+	if err := invalid.UnmarshalText([]byte("5 parsnips")); err == nil {
+		t.Fatal("expected error for unknown alias")
+	}
+
+	if str := fmt.Sprintf("%v", MeasureValue(1.5)); str != "1.5" {
+		t.Fatalf("expected '1.5', got '%s'", str)
+	}
+}
+
+func TestEnlistmentJSONRoundTrip(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"standard",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(enlistment)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `{"group":"time","reference":"Item 1","records":[` +
+		`{"label":"Item 1","value":3600,"text":"1 hour"},` +
+		`{"label":"Item 2","value":900,"text":"15 minute"},` +
+		`{"label":"Item 3","value":60,"text":"1 minute"}]}`
+
+	if string(data) != expected {
+		t.Fatalf("expected %s, got %s", expected, data)
+	}
+
+	var decoded Enlistment
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	err = helperCompareEnlistments(
+		internal.GetFixtureEnlistmentExpected(),
+		&decoded,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEnlistmentUnmarshalJSON(t *testing.T) {
+	var decoded Enlistment
+
+	if err := json.Unmarshal([]byte(`{
+		"reference": "Item 2",
+		"records": [
+			{"label": "Item 1", "value": "1 hour"},
+			{"label": "Item 2", "value": 900}
+		]
+	}`), &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.group.Name() != "time" || decoded.ref.label != "Item 2" {
+		t.Fatalf("unexpected enlistment %+v", &decoded)
+	}
+
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "unknown group",
+			input:   `{"group": "mass", "records": []}`,
+			wantErr: "unit group 'mass' not found",
+		},
+		{
+			name:    "no records",
+			input:   `{"group": "time", "records": []}`,
+			wantErr: "enlistment is empty",
+		},
+		{
+			name:    "numbers without group",
+			input:   `{"records": [{"label": "A", "value": 1}]}`,
+			wantErr: "enlistment unit group cannot be determined",
+		},
+		{
+			name:    "missing label",
+			input:   `{"records": [{"value": "1 hour"}]}`,
+			wantErr: "record 0 missing label",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var enlistment Enlistment
+
+			err := json.Unmarshal([]byte(tc.input), &enlistment)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			if err.Error() != tc.wantErr {
+				t.Errorf("expected error %q, got %q", tc.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestEnlistmentTextAndFormat(t *testing.T) {
+	var enlistment Enlistment
+
+	if err := enlistment.UnmarshalText(
+		[]byte("# Tasks\nItem 1: 90 minutes\nItem 2: 15 minutes\n"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	text, err := enlistment.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "# Tasks\nItem 1: 5400 second\nItem 2: 900 second\n"
+	if string(text) != expected {
+		t.Fatalf("expected %q, got %q", expected, text)
+	}
+
+	if str := fmt.Sprintf("%v", &enlistment); str !=
+		"Item 1: 1 hour, 30 minute\nItem 2: 15 minute" {
+		t.Fatalf("unexpected %%v output %q", str)
+	}
+
+	if str := fmt.Sprintf("%+v", &enlistment); !strings.HasPrefix(
+		str,
+		"group: time, reference: Item 1\n",
+	) {
+		t.Fatalf("unexpected %%+v output %q", str)
+	}
+
+	if str := fmt.Sprintf("%+v", enlistment.records[1]); str !=
+		"Item 2: 15 minute (line 3)" {
+		t.Fatalf("unexpected record %%+v output %q", str)
+	}
+}
+
+func TestEnlistmentMarshalTextRoundTrip(t *testing.T) {
+	var enlistment Enlistment
+
+	if err := enlistment.UnmarshalText(
+		[]byte("#! units: 2\nA: 1 hour\nB: 7 minutes\nC: 1 second\n"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	scaled := enlistment.GetScaled(MeasureValue(1e9 / 3))
+
+	text, err := scaled.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Enlistment
+	if err := decoded.UnmarshalText(text); err != nil {
+		t.Fatalf("UnmarshalText(%q) error = %v", text, err)
+	}
+
+	for exp, res := range internal.IterZip(scaled.records, decoded.records) {
+		if exp.label != res.label || exp.absValue != res.absValue {
+			t.Errorf(
+				"expected %s = %v, got %s = %v",
+				exp.label,
+				exp.absValue,
+				res.label,
+				res.absValue,
+			)
+		}
+	}
+
+	if decoded.Directives().Units != 2 {
+		t.Errorf("expected directives, got %+v", decoded.Directives())
+	}
+}
+
+func TestMeasureValueFormat(t *testing.T) {
+	value := MeasureValue(5400)
+
+	str := fmt.Sprintf("%v|%s|%.1f|%6v|%d", value, value, value, value, value)
+	if str !=
+		"5400|5400|5400.0|  5400|%!d(refscaler.MeasureValue=5400)" {
+		t.Fatalf("unexpected output %q", str)
+	}
+}
+
+func TestRecordTextAndJSON(t *testing.T) {
+	var record Record
+
+	if err := json.Unmarshal(
+		[]byte(`{"label":"x","value":"1 hour"}`),
+		&record,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if str := record.String(); str != "x: 1 hour" {
+		t.Fatalf("unexpected record %q", str)
+	}
+
+	text, err := record.MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(text) != "x: 3600 second" {
+		t.Fatalf("unexpected text %q", text)
+	}
+
+	var decoded Record
+	if err := decoded.UnmarshalText(text); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.String() != record.String() {
+		t.Fatalf("expected %q, got %q", record.String(), decoded.String())
+	}
+
+	if err := decoded.UnmarshalText([]byte("y: 42")); err != nil {
+		t.Fatal(err)
+	}
+
+	if text, err := decoded.MarshalText(); err != nil ||
+		string(text) != "y: 42" {
+		t.Fatalf("unexpected text %q, error %v", text, err)
+	}
+}
+
+func TestRecordFormatIndexed(t *testing.T) {
+	var enlistment Enlistment
+
+	if err := json.Unmarshal([]byte(`{"records": [
+		{"label": "A", "value": "1 hour"},
+		{"label": "B", "value": "2 hours"}
+	]}`), &enlistment); err != nil {
+		t.Fatal(err)
+	}
+
+	if str := fmt.Sprintf("%+v", enlistment.records[1]); str !=
+		"A: 1 hour (record 0)" {
+		t.Fatalf("unexpected record %%+v output %q", str)
+	}
+}
+
+func TestEnlistmentEncodingEmpty(t *testing.T) {
+	enlistment := NewEnlistmentDefault()
+
+	if _, err := enlistment.MarshalJSON(); !errors.Is(
+		err,
+		ErrEmptyEnlistment,
+	) {
+		t.Errorf("MarshalJSON() error = %v", err)
+	}
+
+	if _, err := enlistment.MarshalText(); !errors.Is(
+		err,
+		ErrEmptyEnlistment,
+	) {
+		t.Errorf("MarshalText() error = %v", err)
+	}
+
+	if str := enlistment.String(); str != "" {
+		t.Errorf("String() = %q", str)
+	}
+
+	if str := fmt.Sprintf("%+v", enlistment); str !=
+		"%!v(*refscaler.Enlistment=enlistment has no unit group or records)" {
+		t.Errorf("unexpected %%+v output %q", str)
+	}
+}
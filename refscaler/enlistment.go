@@ -2,11 +2,14 @@ package refscaler
 
 import (
 	"bufio"
+	"cmp"
+	"errors"
 	"fmt"
 	"io"
 	"io/fs"
 	"iter"
 	"math"
+	"math/big"
 	"slices"
 	"strconv"
 	"strings"
@@ -17,40 +20,18 @@ import (
 type RawMeasure struct {
 	value float64
 	alias string
+	text  string
 }
 
 func newRawMeasure(raw string) (rawMeasure RawMeasure, err error) {
-	value, alias, found := strings.Cut(strings.TrimSpace(raw), " ")
-
-	if !found {
-		return RawMeasure{}, fmt.Errorf("raw measure '%s' is malformed", raw)
-	}
-
-	if len(value) == 0 {
-		return RawMeasure{}, fmt.Errorf(
-			"raw measure '%s' missing value",
-			raw,
-		)
-	}
-
-	if len(alias) == 0 {
-		return RawMeasure{}, fmt.Errorf(
-			"raw measure '%s' missing unit alias",
-			raw,
-		)
-	}
-
-	numValue, err := strconv.ParseFloat(value, 64)
+	term, err := units.ParseTerm(raw)
 	if err != nil {
-		return RawMeasure{}, fmt.Errorf(
-			"raw measure '%s' value failed to be parsed: %w",
-			raw,
-			err,
-		)
+		return RawMeasure{}, err
 	}
 
-	rawMeasure.value = numValue
-	rawMeasure.alias = alias
+	rawMeasure.value = term.Value
+	rawMeasure.alias = term.Alias
+	rawMeasure.text = term.Text
 
 	return
 }
@@ -85,36 +66,7 @@ func (r *RawMeasureSlice) getFirstUnitLabel() string {
 type MeasureValue float64
 
 func (m *MeasureValue) toString(num_units int, units units.UnitsSlice) string {
-	result := make([]string, 0, num_units)
-
-	used_units := 0
-	leftover := float64(*m)
-
-	for _, unit := range units {
-		if leftover == 0.0 {
-			break
-		}
-
-		div := leftover / unit.Multiplier
-
-		part := math.Floor(div)
-		if part <= 0.0 {
-			continue
-		}
-
-		used_units++
-
-		if used_units == num_units {
-			result = append(result, fmt.Sprintf("%.02f %s", div, unit.Name))
-			break
-		}
-
-		leftover = leftover - (part * unit.Multiplier)
-
-		result = append(result, fmt.Sprintf("%d %s", int(part), unit.Name))
-	}
-
-	return strings.Join(result, ", ")
+	return units.Decompose(float64(*m), num_units)
 }
 
 func newMeasureFromSlice(
@@ -125,9 +77,14 @@ func newMeasureFromSlice(
 		unit, ok := group.Get(raw.alias)
 
 		if !ok {
+			return measure, group.UnknownAlias(raw.alias)
+		}
+
+		if unit.HasOffset() {
 			return measure, fmt.Errorf(
-				"alias '%s' not found",
-				raw.alias,
+				"%w: '%s'",
+				units.ErrOffsetUnit,
+				unit.Name,
 			)
 		}
 
@@ -166,10 +123,22 @@ func newMeasureValue(
 	return
 }
 
+type entryKind int
+
+const (
+	entryRecord entryKind = iota
+	entryDirective
+	entryComment
+	entryBlank
+)
+
 type Entry struct {
 	label    string
 	measures string
 	line     string
+	raw      string
+	position int
+	kind     entryKind
 }
 
 func splitEntryLine(line string) (label, measures string, err error) {
@@ -203,13 +172,54 @@ func newEntry(line string) (entry Entry, err error) {
 	return
 }
 
+func newDirectiveEntry(line string) (entry Entry, err error) {
+	directive := strings.TrimSpace(strings.TrimPrefix(line, DirectivePrefix))
+
+	key, value, err := splitEntryLine(directive)
+	if err != nil {
+		return Entry{}, fmt.Errorf("malformed directive '%s': %w", line, err)
+	}
+
+	entry.label = strings.ToLower(strings.TrimSpace(key))
+	entry.measures = strings.TrimSpace(value)
+	entry.line = line
+	entry.kind = entryDirective
+
+	return
+}
+
+// Directives holds the defaults an enlistment file declares for itself with
+// lines such as "#! scale: 1 year". Zero values mean the directive is absent.
+type Directives struct {
+	Scale     string
+	Reference string
+	Units     int
+	Group     string
+}
+
+const (
+	DirectiveScale     = "scale"
+	DirectiveReference = "reference"
+	DirectiveUnits     = "units"
+	DirectiveGroup     = "group"
+)
+
+func isDirective(key string) bool {
+	switch key {
+	case DirectiveScale, DirectiveReference, DirectiveUnits, DirectiveGroup:
+		return true
+	}
+
+	return false
+}
+
 type Record struct {
 	label    string
 	absValue MeasureValue
-}
-
-func (r *Record) toString(num_units int, units units.UnitsSlice) string {
-	return fmt.Sprintf("%s: %s", r.label, r.absValue.toString(num_units, units))
+	exact    *big.Rat // set only in exact mode
+	position int
+	group    *units.UnitGroup // units absValue is decomposed into
+	indexed  bool             // position is a record index, not a line
 }
 
 func newRecord(
@@ -217,6 +227,8 @@ func newRecord(
 	group *units.UnitGroup,
 ) (record Record, err error) {
 	record.label = entry.label
+	record.position = entry.position
+	record.group = group
 
 	measure_value, err := newMeasureValue(entry.measures, group)
 	if err != nil {
@@ -232,13 +244,23 @@ type RecordSlice []*Record
 func (r *RecordSlice) GetScaledRecords(
 	scale MeasureValue,
 	ref *Record,
+) (records RecordSlice, scaled_ref *Record) {
+	return r.GetScaledRecordsWith(NewLinearScaling(ref.absValue, scale), ref)
+}
+
+func (r *RecordSlice) GetScaledRecordsWith(
+	strategy ScalingStrategy,
+	ref *Record,
 ) (records RecordSlice, scaled_ref *Record) {
 	records = make(RecordSlice, 0, len(*r))
 
 	for _, rec := range *r {
 		scaled_rec := &Record{
 			label:    rec.label,
-			absValue: rec.absValue / ref.absValue * scale,
+			absValue: strategy.Scale(rec.absValue),
+			position: rec.position,
+			group:    rec.group,
+			indexed:  rec.indexed,
 		}
 		records = append(records, scaled_rec)
 		if rec == ref {
@@ -248,38 +270,47 @@ func (r *RecordSlice) GetScaledRecords(
 	return records, scaled_ref
 }
 
-func (r *RecordSlice) prepareUnitsSlice(
-	group *units.UnitGroup,
-) units.UnitsSlice {
-	slice := make(units.UnitsSlice, 0, group.Length())
-	max := float64((*r)[0].absValue)
+func (r *RecordSlice) minValue() MeasureValue {
+	value := MeasureValue(math.Inf(1))
 
-	for u := range group.IterBackward() {
-		if u.Multiplier > max {
-			continue
-		}
-
-		slice = append(slice, u)
+	for _, rec := range *r {
+		value = min(value, rec.absValue)
 	}
 
-	return slice
+	return value
 }
 
 func (r *RecordSlice) toString(num_units int, group *units.UnitGroup) []string {
-	result := make([]string, 0, len(*r))
-
-	units := r.prepareUnitsSlice(group)
-
-	for _, rec := range *r {
-		result = append(result, rec.toString(num_units, units))
+	formatter := measureFormatter{
+		numUnits: num_units,
+		units:    group.UnitsUpTo(float64(r.maxValue())),
 	}
-	return result
+
+	return r.format(&formatter)
 }
 
 type Enlistment struct {
-	records RecordSlice
-	ref     *Record
-	group   *units.UnitGroup
+	records    RecordSlice
+	ref        *Record
+	group      *units.UnitGroup
+	directives Directives
+	layout     []Entry
+	exact      bool
+	duplicates DuplicatePolicy
+	indexed    bool // positions are record indexes, not line numbers
+	readOnly   bool
+}
+
+// ErrEmptyEnlistment is returned when formatting or encoding an enlistment
+// without a unit group or records, such as the one from NewEnlistmentDefault.
+var ErrEmptyEnlistment = errors.New("enlistment has no unit group or records")
+
+func (e *Enlistment) checkLoaded() error {
+	if e.group == nil || e.ref == nil || len(e.records) == 0 {
+		return ErrEmptyEnlistment
+	}
+
+	return nil
 }
 
 func NewEnlistmentDefault() *Enlistment {
@@ -289,15 +320,7 @@ func NewEnlistmentDefault() *Enlistment {
 }
 
 func (e *Enlistment) sort() {
-	slices.SortFunc(e.records, func(a, b *Record) int {
-		if a.absValue > b.absValue {
-			return -1
-		} else if a.absValue < b.absValue {
-			return 1
-		} else {
-			return 0
-		}
-	})
+	slices.SortStableFunc(e.records, compareRecords(SortByValue))
 }
 
 func (e *Enlistment) addRecord(entry Entry) error {
@@ -306,6 +329,24 @@ func (e *Enlistment) addRecord(entry Entry) error {
 		return err
 	}
 
+	record.indexed = e.indexed
+
+	if e.exact {
+		if record.exact, err = newExactMeasureValue(
+			entry.measures,
+			e.group,
+		); err != nil {
+			return err
+		}
+	}
+
+	if existing := e.findRecord(record.label); existing != nil {
+		add, err := e.mergeDuplicate(existing, &record)
+		if err != nil || !add {
+			return err
+		}
+	}
+
 	e.records = append(e.records, &record)
 
 	if e.ref == nil || e.ref.absValue < record.absValue {
@@ -315,20 +356,46 @@ func (e *Enlistment) addRecord(entry Entry) error {
 	return nil
 }
 
-const CommentPrefix = "#"
+const (
+	CommentPrefix   = "#"
+	DirectivePrefix = "#!"
+)
 
 func iterLines(scanner *bufio.Scanner) iter.Seq2[Entry, error] {
 	return func(yield func(Entry, error) bool) {
-		for scanner.Scan() {
-			line := strings.TrimSpace(scanner.Text())
-			if len(line) == 0 || strings.HasPrefix(line, CommentPrefix) {
-				continue
+		for position := 0; scanner.Scan(); position++ {
+			raw := scanner.Text()
+			line := strings.TrimSpace(raw)
+
+			var entry Entry
+			var err error
+
+			switch {
+			case len(line) == 0:
+				entry.kind = entryBlank
+			case strings.HasPrefix(line, DirectivePrefix):
+				entry, err = newDirectiveEntry(line)
+
+				// a shebang or any other '#!' line not naming a known
+				// directive is an ordinary comment
+				if err != nil || !isDirective(entry.label) {
+					entry, err = Entry{kind: entryComment}, nil
+				}
+			case strings.HasPrefix(line, CommentPrefix):
+				entry.kind = entryComment
+			default:
+				entry, err = newEntry(line)
 			}
-			entry, err := newEntry(line)
 
-			if err != nil && !yield(Entry{}, err) {
+			if err != nil {
+				yield(Entry{}, err)
 				return
 			}
+
+			entry.line = line
+			entry.raw = raw
+			entry.position = position
+
 			if !yield(entry, nil) {
 				return
 			}
@@ -336,6 +403,47 @@ func iterLines(scanner *bufio.Scanner) iter.Seq2[Entry, error] {
 	}
 }
 
+func (e *Enlistment) applyDirective(
+	entry Entry,
+	registry units.UnitRegistry,
+) error {
+	switch entry.label {
+	case DirectiveScale:
+		e.directives.Scale = entry.measures
+	case DirectiveReference:
+		e.directives.Reference = entry.measures
+	case DirectiveUnits:
+		num, err := strconv.Atoi(entry.measures)
+		if err != nil || num <= 0 {
+			return fmt.Errorf(
+				"directive '%s' requires a positive integer, got '%s'",
+				entry.label,
+				entry.measures,
+			)
+		}
+		e.directives.Units = num
+	case DirectiveGroup:
+		if e.group != nil {
+			return fmt.Errorf(
+				"directive '%s' must precede all records",
+				entry.label,
+			)
+		}
+
+		group, ok := registry.Get(entry.measures)
+		if !ok {
+			return fmt.Errorf("unit group '%s' not found", entry.measures)
+		}
+
+		e.directives.Group = entry.measures
+		e.group = group
+	default:
+		return fmt.Errorf("unknown directive '%s'", entry.label)
+	}
+
+	return nil
+}
+
 func (e *Enlistment) determineUnitGroup(
 	entry Entry,
 	registry units.UnitRegistry,
@@ -350,60 +458,65 @@ func (e *Enlistment) determineUnitGroup(
 	group, ok := registry.Find(alias)
 
 	if !ok {
-		return fmt.Errorf(
-			"failed to determine unit group for alias '%s'",
-			alias,
-		)
+		return units.UnknownAlias(alias, registry)
 	}
 
 	e.group = group
 
-	if err := e.addRecord(entry); err != nil {
-		return fmt.Errorf("failed to add entry '%s': %w", entry.line, err)
-	}
-
 	return nil
 }
 
+// locateUnknownAlias lists the groups of registry defining an alias that the
+// group of the enlistment does not know, so the error can tell the user the
+// record uses units of another dimension.
+func locateUnknownAlias(err error, registry units.UnitRegistry) {
+	var unknown *units.UnknownAliasError
+
+	if errors.As(err, &unknown) && unknown.Group != nil {
+		unknown.Locate(registry)
+	}
+}
+
+// loadFromReader reads the enlistment and selects the reference record
+// labelled reference or, when it is empty, named by a '#! reference'
+// directive. The directive is only recorded while reading, so an override
+// replaces it rather than having to pass its check.
 func (e *Enlistment) loadFromReader(
 	reader io.Reader,
 	registry units.UnitRegistry,
+	reference string,
 ) error {
 	scanner := bufio.NewScanner(reader)
 
-	var entry Entry
-	var err error
-	var ok bool
-
-	next, stop := iter.Pull2(iterLines(scanner))
-	defer stop()
-
-	entry, err, ok = next()
-
-	if !ok {
-		return fmt.Errorf("enlistment is empty")
-	}
-
-	if err != nil {
-		return err
-	}
-
-	if err := e.determineUnitGroup(entry, registry); err != nil {
-		return err
-	}
+	for entry, err := range iterLines(scanner) {
+		if err != nil {
+			return err
+		}
 
-	for {
-		entry, err, ok = next()
+		e.layout = append(e.layout, entry)
 
-		if !ok {
-			break
+		switch entry.kind {
+		case entryBlank, entryComment:
+			continue
+		case entryDirective:
+			if err := e.applyDirective(entry, registry); err != nil {
+				return fmt.Errorf(
+					"failed to apply directive '%s': %w",
+					entry.line,
+					err,
+				)
+			}
+			continue
 		}
 
-		if err != nil {
-			return err
+		if e.group == nil {
+			if err := e.determineUnitGroup(entry, registry); err != nil {
+				return err
+			}
 		}
 
 		if err := e.addRecord(entry); err != nil {
+			locateUnknownAlias(err, registry)
 			return fmt.Errorf("failed to add entry '%s': %w", entry.line, err)
 		}
 	}
@@ -412,8 +525,19 @@ func (e *Enlistment) loadFromReader(
 		return err
 	}
 
+	if len(e.records) == 0 {
+		return fmt.Errorf("enlistment is empty")
+	}
+
+	e.suffixDuplicates()
 	e.sort()
 
+	reference = cmp.Or(reference, e.directives.Reference)
+
+	if len(reference) > 0 {
+		return e.SetReference(reference)
+	}
+
 	return nil
 }
 
@@ -422,7 +546,35 @@ func NewEnlistment(
 	units units.UnitRegistry,
 ) (enlistment *Enlistment, err error) {
 	enlistment = NewEnlistmentDefault()
-	err = enlistment.loadFromReader(reader, units)
+	err = enlistment.loadFromReader(reader, units, "")
+	return enlistment, err
+}
+
+// NewEnlistmentExact is NewEnlistment keeping every record as an exact
+// rational number next to its float64 value. Scale it with GetScaledExact.
+func NewEnlistmentExact(
+	reader io.Reader,
+	units units.UnitRegistry,
+) (enlistment *Enlistment, err error) {
+	return NewEnlistmentWith(reader, units, LoadOptions{Exact: true})
+}
+
+// LoadOptions control how NewEnlistmentWith reads an enlistment.
+type LoadOptions struct {
+	Exact      bool            // see NewEnlistmentExact
+	Duplicates DuplicatePolicy // handling of repeated labels
+	Reference  string          // label of the reference, overrides '#!'
+}
+
+func NewEnlistmentWith(
+	reader io.Reader,
+	units units.UnitRegistry,
+	opts LoadOptions,
+) (enlistment *Enlistment, err error) {
+	enlistment = NewEnlistmentDefault()
+	enlistment.exact = opts.Exact
+	enlistment.duplicates = opts.Duplicates
+	err = enlistment.loadFromReader(reader, units, opts.Reference)
 	return enlistment, err
 }
 
@@ -454,20 +606,160 @@ func (e *Enlistment) MakeMeasureValue(measure string) (MeasureValue, error) {
 	return value, nil
 }
 
+func (e *Enlistment) resolveScaleGroup(
+	measure string,
+	registry units.UnitRegistry,
+) (*units.UnitGroup, error) {
+	rawMeasures, err := newRawMeasureSlice(measure)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to create measure value from '%s': %w",
+			measure,
+			err,
+		)
+	}
+
+	alias := rawMeasures.getFirstUnitLabel()
+	matches := lookupAlias(alias, registry)
+
+	if len(matches) > 1 {
+		names := make([]string, 0, len(matches))
+		for _, match := range matches {
+			names = append(
+				names,
+				fmt.Sprintf("'%s' (%s)", match.Unit.Name, match.Key),
+			)
+		}
+
+		return nil, fmt.Errorf(
+			"scale unit '%s' is ambiguous, it may be %s; use a unit name",
+			alias,
+			strings.Join(names, " or "),
+		)
+	}
+
+	if len(matches) == 1 {
+		return matches[0].Group, nil
+	}
+
+	// groups added to the enlistment but missing from the registry
+	if e.group != nil {
+		if _, ok := e.group.Get(alias); ok {
+			return e.group, nil
+		}
+	}
+
+	return nil, units.UnknownAlias(alias, registry)
+}
+
+// lookupAlias returns the units alias resolves to in every group of
+// registry, through the index of an IndexedRegistry when there is one.
+func lookupAlias(alias string, registry units.UnitRegistry) []units.AliasMatch {
+	if indexed, ok := registry.(*units.IndexedRegistry); ok {
+		return indexed.Lookup(alias)
+	}
+
+	var matches []units.AliasMatch
+
+	for key, group := range registry.Groups() {
+		if unit, ok := group.Get(alias); ok {
+			matches = append(matches, units.AliasMatch{
+				Key:   key,
+				Group: group,
+				Unit:  unit,
+			})
+		}
+	}
+
+	return matches
+}
+
+// MakeScale parses a scale that may belong to a different unit group than
+// the enlistment. The group is resolved from the registry by the first
+// alias, which must not name units of several groups, such as "m" for
+// meters and minutes.
+func (e *Enlistment) MakeScale(
+	measure string,
+	registry units.UnitRegistry,
+) (MeasureValue, *units.UnitGroup, error) {
+	group, err := e.resolveScaleGroup(measure, registry)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	value, err := newMeasureValue(measure, group)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return value, group, nil
+}
+
 func (e *Enlistment) GetScaled(scale MeasureValue) *Enlistment {
-	records, ref := e.records.GetScaledRecords(scale, e.ref)
+	return e.GetScaledTo(scale, e.group)
+}
+
+// GetScaledTo scales the enlistment so that the reference equals scale, given
+// in base units of group, and formats the result in the units of that group.
+func (e *Enlistment) GetScaledTo(
+	scale MeasureValue,
+	group *units.UnitGroup,
+) *Enlistment {
+	return e.GetScaledWith(NewLinearScaling(e.ref.absValue, scale), group)
+}
+
+// GetScaledWith scales every record with strategy and formats the result in
+// the units of group.
+func (e *Enlistment) GetScaledWith(
+	strategy ScalingStrategy,
+	group *units.UnitGroup,
+) *Enlistment {
+	records, ref := e.records.GetScaledRecordsWith(strategy, e.ref)
+
+	for _, rec := range records {
+		rec.group = group
+	}
 
 	return &Enlistment{
-		records: records,
-		ref:     ref,
-		group:   e.group,
+		records:    records,
+		ref:        ref,
+		group:      group,
+		directives: e.directives,
+		layout:     e.layout,
 	}
 }
 
 func (e *Enlistment) ToString(num_units int) []string {
+	if e.checkLoaded() != nil {
+		return nil
+	}
+
 	return e.records.toString(num_units, e.group)
 }
 
 func (e *Enlistment) Length() int {
 	return len(e.records)
 }
+
+// Directives returns the defaults declared in the enlistment source.
+func (e *Enlistment) Directives() Directives {
+	return e.directives
+}
+
+// SetReference makes the record with the given label the reference used by
+// GetScaled instead of the largest record. It fails with ErrReadOnly on
+// enlistments returned by EnlistmentBuilder.Build.
+func (e *Enlistment) SetReference(label string) error {
+	if e.readOnly {
+		return ErrReadOnly
+	}
+
+	for _, rec := range e.records {
+		if rec.label == label {
+			e.ref = rec
+			return nil
+		}
+	}
+
+	return fmt.Errorf("reference record '%s' not found", label)
+}
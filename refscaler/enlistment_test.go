@@ -1,8 +1,11 @@
 package refscaler
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/grzadr/refscaler/internal"
 	"github.com/grzadr/refscaler/units"
@@ -122,3 +125,202 @@ func TestEnlistmentToString(t *testing.T) {
 		}
 	}
 }
+
+func TestNewEnlistmentDirectives(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"directives",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Directives{
+		Scale:     "1 year",
+		Reference: "Item 2",
+		Units:     2,
+		Group:     "time",
+	}
+
+	if directives := enlistment.Directives(); directives != expected {
+		t.Fatalf("expected directives %+v, got %+v", expected, directives)
+	}
+
+	if enlistment.ref.label != "Item 2" {
+		t.Fatalf("expected reference 'Item 2', got '%s'", enlistment.ref.label)
+	}
+
+	scale, err := enlistment.MakeMeasureValue(expected.Scale)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ref := enlistment.GetScaled(scale).ref; ref.absValue != scale {
+		t.Fatalf("expected reference scaled to %f, got %f", scale, ref.absValue)
+	}
+}
+
+func TestNewEnlistmentDirectivesInvalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "non-numeric units",
+			input:   "#! units: many\nItem 1: 1 hour",
+			wantErr: "failed to apply directive '#! units: many': directive 'units' requires a positive integer, got 'many'",
+		},
+		{
+			name:    "unknown group",
+			input:   "#! group: mass\nItem 1: 1 kg",
+			wantErr: "failed to apply directive '#! group: mass': unit group 'mass' not found",
+		},
+		{
+			name:    "group after records",
+			input:   "Item 1: 1 hour\n#! group: time",
+			wantErr: "failed to apply directive '#! group: time': directive 'group' must precede all records",
+		},
+		{
+			name:    "missing reference",
+			input:   "#! reference: Item 9\nItem 1: 1 hour",
+			wantErr: "reference record 'Item 9' not found",
+		},
+		{
+			name:    "directives only",
+			input:   "#! scale: 1 year",
+			wantErr: "enlistment is empty",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewEnlistment(
+				strings.NewReader(tc.input),
+				units.EmbeddedUnitRegistry,
+			)
+
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if err.Error() != tc.wantErr {
+				t.Errorf("expected error %q, got %q", tc.wantErr, err.Error())
+			}
+		})
+	}
+}
+
+func TestNewEnlistmentUnknownDirectives(t *testing.T) {
+	enlistment, err := NewEnlistment(
+		strings.NewReader("#!/usr/bin/env refscaler scale\n"+
+			"#! colour: red\n#! units: 2\nItem 1: 1 hour"),
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if directives := enlistment.Directives(); directives.Units != 2 {
+		t.Errorf("expected units directive 2, got %+v", directives)
+	}
+
+	if enlistment.Length() != 1 {
+		t.Errorf("expected 1 record, got %d", enlistment.Length())
+	}
+}
+
+func TestNewEnlistmentReferenceOverride(t *testing.T) {
+	enlistment, err := NewEnlistmentWith(
+		strings.NewReader("#! reference: Nope\nA: 1 hour\nB: 5 minutes\n"),
+		units.EmbeddedUnitRegistry,
+		LoadOptions{Reference: "B"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if enlistment.ref.label != "B" {
+		t.Errorf("reference = %s, want B", enlistment.ref.label)
+	}
+
+	if directives := enlistment.Directives(); directives.Reference != "Nope" {
+		t.Errorf("directive reference = %q, want Nope", directives.Reference)
+	}
+}
+
+func TestNewEnlistmentOffsetUnits(t *testing.T) {
+	registry, err := units.NewUnitRegistryFiles(fstest.MapFS{
+		"units/temperature.json": {Data: []byte(`[
+			{"name": "kelvin", "value": 1, "aliases": ["K"]},
+			{"name": "celsius", "value": 1, "offset": 273.15, "aliases": ["C"]}
+		]`)},
+	}, "units")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, exact := range []bool{false, true} {
+		_, err := NewEnlistmentWith(
+			strings.NewReader("Morning: 280 K\nNoon: 20 C\n"),
+			&registry,
+			LoadOptions{Exact: exact},
+		)
+		if !errors.Is(err, units.ErrOffsetUnit) {
+			t.Errorf("exact %t: error = %v, want ErrOffsetUnit", exact, err)
+		}
+	}
+}
+
+func TestEnlistmentGetScaledTo(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"standard",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scale, group, err := enlistment.MakeScale(
+		"4 kilometers",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := group.Get("meter"); !ok {
+		t.Fatal("expected scale to resolve to the length unit group")
+	}
+
+	scaled := enlistment.GetScaledTo(scale, group)
+
+	expected := []internal.TestEnlistment{
+		{Label: "Item 1", Value: 4000},
+		{Label: "Item 2", Value: 1000},
+		{Label: "Item 3", Value: 4000.0 / 60},
+	}
+
+	if err := helperCompareEnlistments(expected, scaled); err != nil {
+		t.Fatal(err)
+	}
+
+	if str := scaled.ToString(1); str[1] != "Item 2: 1.00 kilometer" {
+		t.Fatalf("expected 'Item 2: 1.00 kilometer', got '%s'", str[1])
+	}
+
+	if _, _, err := enlistment.MakeScale(
+		"4 parsnips",
+		units.EmbeddedUnitRegistry,
+	); err == nil {
+		t.Fatal("expected error for unknown scale alias")
+	}
+
+	_, _, err = enlistment.MakeScale("100 m", units.EmbeddedUnitRegistry)
+	wantErr := "scale unit 'm' is ambiguous, it may be " +
+		"'meter' (length) or 'minute' (time); use a unit name"
+	if err == nil || err.Error() != wantErr {
+		t.Errorf("error = %v, want %q", err, wantErr)
+	}
+}
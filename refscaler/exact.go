@@ -0,0 +1,186 @@
+package refscaler
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+// ExactPrecision is the number of decimals printed for fractional parts of
+// exact values, matching the float64 formatting.
+const ExactPrecision = 2
+
+func newExactMeasureFromSlice(
+	measures RawMeasureSlice,
+	group *units.UnitGroup,
+) (*big.Rat, error) {
+	measure := new(big.Rat)
+
+	for _, raw := range measures {
+		unit, ok := group.Get(raw.alias)
+		if !ok {
+			return nil, group.UnknownAlias(raw.alias)
+		}
+
+		if unit.HasOffset() {
+			return nil, fmt.Errorf("%w: '%s'", units.ErrOffsetUnit, unit.Name)
+		}
+
+		value, ok := new(big.Rat).SetString(raw.text)
+		if !ok {
+			return nil, fmt.Errorf(
+				"value '%s' cannot be represented exactly",
+				raw.text,
+			)
+		}
+
+		measure.Add(measure, value.Mul(value, unit.ExactMultiplier()))
+	}
+
+	if measure.Sign() == 0 {
+		return nil, fmt.Errorf("value cannot equal 0")
+	}
+
+	return measure, nil
+}
+
+func newExactMeasureValue(
+	measures string,
+	group *units.UnitGroup,
+) (*big.Rat, error) {
+	rawMeasures, err := newRawMeasureSlice(measures)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to create measure value from '%s': %w",
+			measures,
+			err,
+		)
+	}
+
+	measure, err := newExactMeasureFromSlice(rawMeasures, group)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to create measure value from '%s': %w",
+			measures,
+			err,
+		)
+	}
+
+	return measure, nil
+}
+
+// exactToString is MeasureValue.toString computed with rational arithmetic.
+func exactToString(
+	value *big.Rat,
+	num_units int,
+	units units.UnitsSlice,
+	remainder bool,
+) string {
+	result := make([]string, 0, num_units)
+
+	used_units := 0
+	leftover := new(big.Rat).Set(value)
+
+	for i, unit := range units {
+		if leftover.Sign() == 0 {
+			break
+		}
+
+		multiplier := unit.ExactMultiplier()
+		div := new(big.Rat).Quo(leftover, multiplier)
+
+		part := new(big.Int).Quo(div.Num(), div.Denom())
+		last := remainder && i == len(units)-1
+
+		if part.Sign() <= 0 && !last {
+			continue
+		}
+
+		used_units++
+
+		if used_units == num_units || (last && !div.IsInt()) {
+			result = append(result, fmt.Sprintf(
+				"%s %s",
+				div.FloatString(ExactPrecision),
+				unit.Name,
+			))
+			break
+		}
+
+		leftover.Sub(
+			leftover,
+			multiplier.Mul(multiplier, new(big.Rat).SetInt(part)),
+		)
+
+		result = append(result, fmt.Sprintf("%s %s", part, unit.Name))
+	}
+
+	if len(result) == 0 && len(units) > 0 {
+		return fmt.Sprintf("0 %s", units[len(units)-1].Name)
+	}
+
+	return strings.Join(result, ", ")
+}
+
+// MakeExactScale is MakeScale returning the scale as an exact rational.
+func (e *Enlistment) MakeExactScale(
+	measure string,
+	registry units.UnitRegistry,
+) (*big.Rat, *units.UnitGroup, error) {
+	group, err := e.resolveScaleGroup(measure, registry)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	value, err := newExactMeasureValue(measure, group)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return value, group, nil
+}
+
+// GetScaledExact scales an enlistment created with NewEnlistmentExact so that
+// the reference equals scale, given in base units of group, without rounding.
+func (e *Enlistment) GetScaledExact(
+	scale *big.Rat,
+	group *units.UnitGroup,
+) (*Enlistment, error) {
+	if !e.exact {
+		return nil, fmt.Errorf("enlistment was not loaded in exact mode")
+	}
+
+	ratio := new(big.Rat).Quo(scale, e.ref.exact)
+
+	records := make(RecordSlice, 0, len(e.records))
+	var ref *Record
+
+	for _, rec := range e.records {
+		exact := new(big.Rat).Mul(rec.exact, ratio)
+		value, _ := exact.Float64()
+
+		scaled := &Record{
+			label:    rec.label,
+			absValue: MeasureValue(value),
+			exact:    exact,
+			position: rec.position,
+			group:    group,
+		}
+		records = append(records, scaled)
+
+		if rec == e.ref {
+			ref = scaled
+		}
+	}
+
+	return &Enlistment{
+		records:    records,
+		ref:        ref,
+		group:      group,
+		directives: e.directives,
+		layout:     e.layout,
+		exact:      true,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package refscaler
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+func TestEnlistmentGetScaledExact(t *testing.T) {
+	enlistment, err := NewEnlistmentExact(
+		strings.NewReader("A: 0.1 m\nB: 0.7 m\n"),
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := enlistment.SetReference("A"); err != nil {
+		t.Fatal(err)
+	}
+
+	scale, group, err := enlistment.MakeExactScale(
+		"1 meter",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scaled, err := enlistment.GetScaledExact(scale, group)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := big.NewRat(7, 1); scaled.records[0].exact.Cmp(exp) != 0 {
+		t.Fatalf("expected exactly %s, got %s", exp, scaled.records[0].exact)
+	}
+
+	expected := []string{"B: 7 meter", "A: 1 meter"}
+
+	for exp, res := range internal.IterZip(expected, scaled.ToString(5)) {
+		if exp != res {
+			t.Fatalf("expected '%s' got '%s'", exp, res)
+		}
+	}
+}
+
+func TestEnlistmentGetScaledExactToString(t *testing.T) {
+	enlistment, err := NewEnlistmentExact(
+		strings.NewReader(`Item 1: 0.75 hour, 15 minutes
+			Item 2: 15 minutes
+			Item 3: 60 seconds`),
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scale, group, err := enlistment.MakeExactScale(
+		"1 year",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scaled, err := enlistment.GetScaledExact(scale, group)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for exp, res := range internal.IterZip(
+		internal.GetFixtureScaledEnslistmentToString(),
+		scaled.ToString(3),
+	) {
+		if exp != res {
+			t.Fatalf("expected '%s' got '%s'", exp, res)
+		}
+	}
+
+	single, err := scaled.ToStringWith(FormatOptions{Unit: "week"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := "Item 3: 0.87 week"; single[2] != exp {
+		t.Fatalf("expected '%s' got '%s'", exp, single[2])
+	}
+}
+
+func TestEnlistmentGetScaledExactInvalid(t *testing.T) {
+	enlistment, err := NewEnlistment(
+		strings.NewReader("A: 1 m"),
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := enlistment.GetScaledExact(
+		big.NewRat(1, 1),
+		enlistment.group,
+	); err == nil {
+		t.Fatal("expected error for enlistment not loaded in exact mode")
+	}
+}
@@ -0,0 +1,120 @@
+package refscaler
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+// MeasureFlag is a flag.Value accepting measures such as "1 hour, 30 minutes".
+// When Group is set only its aliases are accepted, otherwise the group is
+// resolved from Registry, or units.DecodingRegistry when Registry is nil.
+type MeasureFlag struct {
+	Value    MeasureValue
+	Group    *units.UnitGroup
+	Registry units.UnitRegistry
+	NumUnits int // units used by String and LogValue, DefaultNumUnits if 0
+}
+
+// NewMeasureFlag returns a flag restricted to group with an initial value
+// parsed from measure.
+func NewMeasureFlag(
+	group *units.UnitGroup,
+	measure string,
+) (*MeasureFlag, error) {
+	flag := &MeasureFlag{Group: group}
+
+	if len(measure) == 0 {
+		return flag, nil
+	}
+
+	if err := flag.Set(measure); err != nil {
+		return nil, err
+	}
+
+	return flag, nil
+}
+
+func (f *MeasureFlag) registry() units.UnitRegistry {
+	if f.Registry != nil {
+		return f.Registry
+	}
+
+	return units.DecodingRegistry()
+}
+
+// Set parses measure the way Enlistment.MakeMeasureValue does.
+func (f *MeasureFlag) Set(measure string) error {
+	group := f.Group
+
+	if group == nil {
+		rawMeasures, err := newRawMeasureSlice(measure)
+		if err != nil {
+			return fmt.Errorf(
+				"failed to create measure value from '%s': %w",
+				measure,
+				err,
+			)
+		}
+
+		alias := rawMeasures.getFirstUnitLabel()
+
+		var ok bool
+		if group, ok = f.registry().Find(alias); !ok {
+			return units.UnknownAlias(alias, f.registry())
+		}
+	}
+
+	value, err := newMeasureValue(measure, group)
+	if err != nil {
+		locateUnknownAlias(err, f.registry())
+		return err
+	}
+
+	f.Value = value
+	f.Group = group
+
+	return nil
+}
+
+// Get implements flag.Getter and returns the MeasureValue.
+func (f *MeasureFlag) Get() any {
+	return f.Value
+}
+
+func (f *MeasureFlag) numUnits() int {
+	if f.NumUnits > 0 {
+		return f.NumUnits
+	}
+
+	return units.DefaultNumUnits
+}
+
+// String decomposes the value into units of its group.
+func (f *MeasureFlag) String() string {
+	if f == nil || f.Group == nil || f.Value == 0 {
+		return ""
+	}
+
+	return f.Value.toString(
+		f.numUnits(),
+		f.Group.UnitsUpTo(math.Abs(float64(f.Value))),
+	)
+}
+
+// LogValue implements slog.LogValuer with the value in base units, the name
+// of the unit group and the decomposed text.
+func (f *MeasureFlag) LogValue() slog.Value {
+	group := ""
+	if f.Group != nil {
+		group = f.Group.Name()
+	}
+
+	return slog.GroupValue(
+		slog.Float64("value", float64(f.Value)),
+		slog.String("group", group),
+		slog.String("text", f.String()),
+	)
+}
@@ -0,0 +1,140 @@
+package refscaler
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"log/slog"
+	"testing"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+func TestMeasureFlagSet(t *testing.T) {
+	testCases := []struct {
+		name     string
+		args     []string
+		expected MeasureValue
+		group    string
+		text     string
+		wantErr  bool
+	}{
+		{
+			name:     "compound",
+			args:     []string{"-span", "1 hour, 15 minutes"},
+			expected: 4500,
+			group:    "time",
+			text:     "1 hour, 15 minute",
+		},
+		{
+			name:     "negative",
+			args:     []string{"-span", "-90 minutes"},
+			expected: -5400,
+			group:    "time",
+			text:     "-1 hour, 30 minute",
+		},
+		{
+			name:     "length",
+			args:     []string{"-span", "2 km"},
+			expected: 2000,
+			group:    "length",
+		},
+		{
+			name:    "unknown alias",
+			args:    []string{"-span", "3 parsnips"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			args:    []string{"-span", "x hours"},
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var span MeasureFlag
+
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(&bytes.Buffer{})
+			flags.Var(&span, "span", "span of the timeline")
+
+			err := flags.Parse(tc.args)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tc.wantErr)
+			}
+
+			if tc.wantErr {
+				return
+			}
+
+			if span.Value != tc.expected {
+				t.Errorf("Value = %v, want %v", span.Value, tc.expected)
+			}
+
+			if span.Group.Name() != tc.group {
+				t.Errorf("Group = %s, want %s", span.Group.Name(), tc.group)
+			}
+
+			if len(tc.text) > 0 && span.String() != tc.text {
+				t.Errorf("String() = %q, want %q", span.String(), tc.text)
+			}
+		})
+	}
+}
+
+func TestMeasureFlagRestrictedGroup(t *testing.T) {
+	group, _ := units.EmbeddedUnitRegistry.Get("time")
+
+	span, err := NewMeasureFlag(group, "1 day")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if span.Get() != MeasureValue(86400) {
+		t.Errorf("Get() = %v, want 86400", span.Get())
+	}
+
+	if err := span.Set("5 meters"); err == nil {
+		t.Error("Set() accepted alias outside of the group")
+	}
+
+	if span.Value != 86400 {
+		t.Errorf("failed Set() changed value to %v", span.Value)
+	}
+}
+
+func TestMeasureFlagLogValue(t *testing.T) {
+	var span MeasureFlag
+	if err := span.Set("90 minutes"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("scaling", "span", &span)
+
+	var record struct {
+		Span struct {
+			Value float64 `json:"value"`
+			Group string  `json:"group"`
+			Text  string  `json:"text"`
+		} `json:"span"`
+	}
+
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+
+	if record.Span.Value != 5400 {
+		t.Errorf("value = %v, want 5400", record.Span.Value)
+	}
+
+	if record.Span.Group != "time" {
+		t.Errorf("group = %s, want time", record.Span.Group)
+	}
+
+	if record.Span.Text != "1 hour, 30 minute" {
+		t.Errorf("text = %q, want %q", record.Span.Text, "1 hour, 30 minute")
+	}
+}
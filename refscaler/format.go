@@ -0,0 +1,297 @@
+package refscaler
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+// FormatOptions controls which units are used to print values. Units are
+// referred to by any of their aliases.
+//
+// By default all values share the units at or below the largest value. With
+// BestFit every value leads with the largest unit whose count falls within
+// [FitMin, FitMax), 1 to 1000 unless set.
+//
+// Whatever remains below the smallest unit in use is dropped unless
+// Remainder or BestFit is set, which print it as a fraction of that unit.
+type FormatOptions struct {
+	NumUnits  int      // maximum number of units printed per value
+	Allow     []string // when set, only these units are used
+	Deny      []string // units that are never used
+	Unit      string   // when set, every value is printed in this unit alone
+	Systems   []string // when set, only units of these systems are used
+	BestFit   bool     // pick the leading unit for each value separately
+	FitMin    float64  // lower bound of the leading unit count
+	FitMax    float64  // upper bound of the leading unit count
+	Remainder bool     // keep the remainder on the smallest unit
+}
+
+const (
+	DefaultFitMin = 1.0
+	DefaultFitMax = 1000.0
+)
+
+type measureFormatter struct {
+	numUnits  int
+	units     units.UnitsSlice
+	single    *units.Unit
+	base      *units.Unit // prints the exact value in the base unit
+	bestFit   bool
+	fitMin    float64
+	fitMax    float64
+	remainder bool
+}
+
+func resolveUnits(
+	group *units.UnitGroup,
+	aliases []string,
+) (map[*units.Unit]bool, error) {
+	resolved := make(map[*units.Unit]bool, len(aliases))
+
+	for _, alias := range aliases {
+		unit, ok := group.Get(alias)
+		if !ok {
+			return nil, group.UnknownAlias(alias)
+		}
+
+		resolved[unit] = true
+	}
+
+	return resolved, nil
+}
+
+// checkSystems rejects system names no unit of group is tagged with, which
+// would otherwise filter nothing out. Groups whose units carry no systems,
+// like time, belong to every system and accept any name.
+func checkSystems(group *units.UnitGroup, systems []string) error {
+	known := group.Systems()
+	if len(known) == 0 {
+		return nil
+	}
+
+	for _, system := range systems {
+		if !slices.Contains(known, system) {
+			return fmt.Errorf(
+				"unknown unit system '%s' in group '%s', expected one of: %s",
+				system,
+				group.Name(),
+				strings.Join(known, ", "),
+			)
+		}
+	}
+
+	return nil
+}
+
+// newMeasureFormatter picks the units for values not larger than max. Allowed
+// units are used regardless of max, so they can be forced on small values.
+func newMeasureFormatter(
+	opts FormatOptions,
+	group *units.UnitGroup,
+	max float64,
+) (formatter measureFormatter, err error) {
+	formatter.numUnits = opts.NumUnits
+	formatter.bestFit = opts.BestFit
+	formatter.remainder = opts.Remainder || opts.BestFit
+	formatter.fitMin = cmp.Or(opts.FitMin, DefaultFitMin)
+	formatter.fitMax = cmp.Or(opts.FitMax, DefaultFitMax)
+
+	if formatter.bestFit &&
+		(formatter.fitMin <= 0 || formatter.fitMin >= formatter.fitMax) {
+		return formatter, fmt.Errorf(
+			"best fit range [%g, %g) is invalid",
+			formatter.fitMin,
+			formatter.fitMax,
+		)
+	}
+
+	if len(opts.Unit) > 0 {
+		unit, ok := group.Get(opts.Unit)
+		if !ok {
+			return formatter, group.UnknownAlias(opts.Unit)
+		}
+
+		formatter.single = unit
+
+		return formatter, nil
+	}
+
+	if err := checkSystems(group, opts.Systems); err != nil {
+		return formatter, err
+	}
+
+	allowed, err := resolveUnits(group, opts.Allow)
+	if err != nil {
+		return formatter, err
+	}
+
+	denied, err := resolveUnits(group, opts.Deny)
+	if err != nil {
+		return formatter, err
+	}
+
+	candidates := group.UnitsUpTo(max)
+	if len(allowed) > 0 || opts.BestFit {
+		candidates = group.UnitsUpTo(math.Inf(1))
+	}
+
+	for _, unit := range candidates {
+		if (len(allowed) == 0 || allowed[unit]) && !denied[unit] &&
+			unit.InSystems(opts.Systems...) {
+			formatter.units = append(formatter.units, unit)
+		}
+	}
+
+	if len(formatter.units) == 0 &&
+		(len(allowed) > 0 || len(denied) > 0 || len(opts.Systems) > 0) {
+		return formatter, fmt.Errorf("no units left to format values")
+	}
+
+	return formatter, nil
+}
+
+func (f *measureFormatter) format(value MeasureValue) string {
+	if f.base != nil {
+		return fmt.Sprintf("%s %s", value, f.base.Name)
+	}
+
+	if f.single != nil {
+		return fmt.Sprintf(
+			"%.02f %s",
+			float64(value)/f.single.Multiplier,
+			f.single.Name,
+		)
+	}
+
+	units := f.units
+	if f.bestFit {
+		units = units[f.leadingUnit(value):]
+	}
+
+	if f.remainder {
+		return units.DecomposeRemainder(float64(value), f.numUnits)
+	}
+
+	return value.toString(f.numUnits, units)
+}
+
+func (f *measureFormatter) formatExact(value *big.Rat) string {
+	if f.base != nil {
+		approx, _ := value.Float64()
+		return f.format(MeasureValue(approx))
+	}
+
+	if f.single != nil {
+		return fmt.Sprintf(
+			"%s %s",
+			new(big.Rat).Quo(value, f.single.ExactMultiplier()).FloatString(
+				ExactPrecision,
+			),
+			f.single.Name,
+		)
+	}
+
+	units := f.units
+	if f.bestFit {
+		approx, _ := value.Float64()
+		units = units[f.leadingUnit(MeasureValue(approx)):]
+	}
+
+	return exactToString(value, f.numUnits, units, f.remainder)
+}
+
+// leadingUnit returns the index of the largest unit whose count of value
+// falls within the fit range. When the range falls between two units, the
+// one whose count is proportionally closer to the range wins. Values too
+// small for every unit lead with the smallest unit.
+func (f *measureFormatter) leadingUnit(value MeasureValue) int {
+	for i, unit := range f.units {
+		count := float64(value) / unit.Multiplier
+
+		if count < f.fitMin {
+			continue
+		}
+
+		if count < f.fitMax || i == 0 {
+			return i
+		}
+
+		// count overshoots the range while the larger unit falls short
+		larger := float64(value) / f.units[i-1].Multiplier
+		if f.fitMin/larger < count/f.fitMax {
+			return i - 1
+		}
+
+		return i
+	}
+
+	return max(len(f.units)-1, 0)
+}
+
+func (r *Record) format(formatter *measureFormatter) string {
+	if r.exact != nil {
+		return fmt.Sprintf("%s: %s", r.label, formatter.formatExact(r.exact))
+	}
+
+	return fmt.Sprintf("%s: %s", r.label, formatter.format(r.absValue))
+}
+
+func (r *RecordSlice) format(formatter *measureFormatter) []string {
+	result := make([]string, 0, len(*r))
+
+	for _, rec := range *r {
+		result = append(result, rec.format(formatter))
+	}
+
+	return result
+}
+
+func (r *RecordSlice) maxValue() MeasureValue {
+	value := MeasureValue(0)
+
+	for _, rec := range *r {
+		value = max(value, rec.absValue)
+	}
+
+	return value
+}
+
+func (e *Enlistment) newMeasureFormatter(
+	opts FormatOptions,
+) (measureFormatter, error) {
+	if err := e.checkLoaded(); err != nil {
+		return measureFormatter{}, err
+	}
+
+	return newMeasureFormatter(opts, e.group, float64(e.records.maxValue()))
+}
+
+// ToStringWith formats every record using the units selected by opts.
+func (e *Enlistment) ToStringWith(opts FormatOptions) ([]string, error) {
+	formatter, err := e.newMeasureFormatter(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.records.format(&formatter), nil
+}
+
+// WriteDocumentWith is WriteDocument using the units selected by opts.
+func (e *Enlistment) WriteDocumentWith(
+	w io.Writer,
+	opts FormatOptions,
+) error {
+	formatter, err := e.newMeasureFormatter(opts)
+	if err != nil {
+		return err
+	}
+
+	return e.writeDocument(w, &formatter)
+}
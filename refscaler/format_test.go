@@ -0,0 +1,335 @@
+package refscaler
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+func TestEnlistmentToStringWith(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"standard",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scale, _ := enlistment.MakeMeasureValue("1 year")
+	scaled := enlistment.GetScaled(scale)
+
+	testCases := []struct {
+		name     string
+		opts     FormatOptions
+		expected []string
+	}{
+		{
+			name: "allow",
+			opts: FormatOptions{NumUnits: 3, Allow: []string{"days", "h"}},
+			expected: []string{
+				"Item 1: 365 day",
+				"Item 2: 91 day, 6 hour",
+				"Item 3: 6 day, 2 hour",
+			},
+		},
+		{
+			name: "deny",
+			opts: FormatOptions{NumUnits: 2, Deny: []string{"month", "week"}},
+			expected: []string{
+				"Item 1: 1 year",
+				"Item 2: 91 day, 6.00 hour",
+				"Item 3: 6 day, 2.00 hour",
+			},
+		},
+		{
+			name: "single unit",
+			opts: FormatOptions{NumUnits: 3, Unit: "hr"},
+			expected: []string{
+				"Item 1: 8760.00 hour",
+				"Item 2: 2190.00 hour",
+				"Item 3: 146.00 hour",
+			},
+		},
+		{
+			name: "remainder dropped by default",
+			opts: FormatOptions{NumUnits: 3, Allow: []string{"week", "day"}},
+			expected: []string{
+				"Item 1: 52 week, 1 day",
+				"Item 2: 13 week",
+				"Item 3: 6 day",
+			},
+		},
+		{
+			name: "remainder in last allowed unit",
+			opts: FormatOptions{
+				NumUnits:  3,
+				Allow:     []string{"week", "day"},
+				Remainder: true,
+			},
+			expected: []string{
+				"Item 1: 52 week, 1 day",
+				"Item 2: 13 week, 0.25 day",
+				"Item 3: 6.08 day",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := scaled.ToStringWith(tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result) != len(tc.expected) {
+				t.Fatalf(
+					"expected %d lines, got %d",
+					len(tc.expected),
+					len(result),
+				)
+			}
+
+			for exp, res := range internal.IterZip(tc.expected, result) {
+				if exp != res {
+					t.Fatalf("expected '%s' got '%s'", exp, res)
+				}
+			}
+		})
+	}
+}
+
+func TestEnlistmentToStringWithInvalid(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"standard",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name    string
+		opts    FormatOptions
+		wantErr string
+	}{
+		{
+			name:    "unknown allowed unit",
+			opts:    FormatOptions{Allow: []string{"meter"}},
+			wantErr: "alias 'meter' not found",
+		},
+		{
+			name:    "unknown single unit",
+			opts:    FormatOptions{Unit: "fortnight"},
+			wantErr: "alias 'fortnight' not found",
+		},
+		{
+			name:    "everything denied",
+			opts:    FormatOptions{Allow: []string{"day"}, Deny: []string{"d"}},
+			wantErr: "no units left to format values",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := enlistment.ToStringWith(tc.opts)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+
+			if err.Error() != tc.wantErr {
+				t.Errorf("expected error %q, got %q", tc.wantErr, err.Error())
+			}
+		})
+	}
+
+	var builder strings.Builder
+	if err := enlistment.WriteDocumentWith(
+		&builder,
+		FormatOptions{Unit: "minute"},
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(builder.String(), "Item 1: 60.00 minute\n") {
+		t.Fatalf("unexpected document %q", builder.String())
+	}
+}
+
+func TestEnlistmentToStringWithBestFit(t *testing.T) {
+	enlistment, err := NewEnlistment(
+		strings.NewReader(`Universe: 13.8e9 years
+			Trip: 9 days
+			Holiday: 5 days
+			Day: 1 day
+			Meeting: 90 minutes
+			Blink: 0.3 s
+			`),
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		opts     FormatOptions
+		expected []string
+	}{
+		{
+			name: "shared units",
+			opts: FormatOptions{NumUnits: 2},
+			expected: []string{
+				"Universe: 13800000 millennium",
+				"Trip: 1 week, 2.00 day",
+				"Holiday: 5 day",
+				"Day: 1 day",
+				"Meeting: 1 hour, 30.00 minute",
+				"Blink: 0 second",
+			},
+		},
+		{
+			name: "default range",
+			opts: FormatOptions{NumUnits: 2, BestFit: true},
+			expected: []string{
+				"Universe: 13800000 millennium",
+				"Trip: 1 week, 2.00 day",
+				"Holiday: 5 day",
+				"Day: 1 day",
+				"Meeting: 1 hour, 30.00 minute",
+				"Blink: 0.30 second",
+			},
+		},
+		{
+			name: "minimum count",
+			opts: FormatOptions{NumUnits: 2, BestFit: true, FitMin: 2},
+			expected: []string{
+				"Universe: 13800000 millennium",
+				"Trip: 9 day",
+				"Holiday: 5 day",
+				"Day: 24 hour",
+				"Meeting: 90 minute",
+				"Blink: 0.30 second",
+			},
+		},
+		{
+			// 9 day fall short of 10-100 less than 216 hour overshoot it,
+			// 5 day fall short more than 120 hour overshoot it
+			name: "range between units",
+			opts: FormatOptions{
+				NumUnits: 2,
+				BestFit:  true,
+				FitMin:   10,
+				FitMax:   100,
+			},
+			expected: []string{
+				"Universe: 13800000 millennium",
+				"Trip: 9 day",
+				"Holiday: 120 hour",
+				"Day: 24 hour",
+				"Meeting: 90 minute",
+				"Blink: 0.30 second",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := enlistment.ToStringWith(tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(result) != len(tc.expected) {
+				t.Fatalf("expected %d lines, got %v", len(tc.expected), result)
+			}
+
+			for exp, res := range internal.IterZip(tc.expected, result) {
+				if exp != res {
+					t.Fatalf("expected '%s' got '%s'", exp, res)
+				}
+			}
+		})
+	}
+
+	if _, err := enlistment.ToStringWith(FormatOptions{
+		BestFit: true,
+		FitMin:  10,
+		FitMax:  5,
+	}); err == nil {
+		t.Fatal("expected error for invalid best fit range")
+	}
+}
+
+func TestMeasureValueToStringZero(t *testing.T) {
+	group, _ := units.EmbeddedUnitRegistry.Get("time")
+	value := MeasureValue(0)
+
+	if str := value.toString(2, group.UnitsUpTo(60)); str != "0 second" {
+		t.Fatalf("expected '0 second', got '%s'", str)
+	}
+}
+
+func TestEnlistmentToStringWithSystems(t *testing.T) {
+	enlistment, err := NewEnlistment(
+		strings.NewReader("Room: 12 ft, 6 in\nDesk: 30 in"),
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := enlistment.ToStringWith(FormatOptions{
+		NumUnits: 2,
+		Systems:  []string{"metric"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"Room: 3 meter, 8.10 decimeter",
+		"Desk: 7 decimeter, 6.20 centimeter",
+	}
+
+	for exp, res := range internal.IterZip(expected, result) {
+		if exp != res {
+			t.Fatalf("expected '%s' got '%s'", exp, res)
+		}
+	}
+
+	_, err = enlistment.ToStringWith(FormatOptions{
+		Systems: []string{"metric", "klingon"},
+	})
+
+	wantErr := "unknown unit system 'klingon' in group 'length', " +
+		"expected one of: astronomical, imperial, metric, nautical, us"
+	if err == nil || err.Error() != wantErr {
+		t.Fatalf("expected error %q, got %v", wantErr, err)
+	}
+}
+
+func TestEnlistmentToStringWithSystemsUntagged(t *testing.T) {
+	enlistment, err := NewEnlistment(
+		strings.NewReader("Task: 90 minutes"),
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := enlistment.ToStringWith(FormatOptions{
+		NumUnits: 2,
+		Systems:  []string{"metric"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := "Task: 1 hour, 30.00 minute"; result[0] != exp {
+		t.Fatalf("expected '%s' got '%s'", exp, result[0])
+	}
+}
@@ -0,0 +1,222 @@
+package refscaler
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+)
+
+// ScalingStrategy maps record values onto scaled values. Inverse undoes
+// Scale, so every scaled value can be traced back to the value it came from.
+type ScalingStrategy interface {
+	Scale(value MeasureValue) MeasureValue
+	Inverse(scaled MeasureValue) MeasureValue
+}
+
+// LinearScaling multiplies every value by target/ref:
+//
+//	scaled = value / ref * target
+//	value  = scaled / target * ref
+type LinearScaling struct {
+	ref    MeasureValue
+	target MeasureValue
+}
+
+func NewLinearScaling(ref, target MeasureValue) LinearScaling {
+	return LinearScaling{ref: ref, target: target}
+}
+
+func (s LinearScaling) Scale(value MeasureValue) MeasureValue {
+	return value / s.ref * s.target
+}
+
+func (s LinearScaling) Inverse(scaled MeasureValue) MeasureValue {
+	return scaled / s.target * s.ref
+}
+
+// LogScaling compresses values spanning many orders of magnitude. The floor,
+// usually the smallest record, sets where the curve starts to flatten:
+//
+//	scaled = target * log(1 + value/floor) / log(1 + ref/floor)
+//	value  = floor * (exp(scaled/target * log(1 + ref/floor)) - 1)
+type LogScaling struct {
+	ref    MeasureValue
+	target MeasureValue
+	floor  MeasureValue
+}
+
+func NewLogScaling(ref, target, floor MeasureValue) (LogScaling, error) {
+	if floor <= 0 {
+		return LogScaling{}, fmt.Errorf(
+			"logarithmic scaling requires a positive floor, got %g",
+			floor,
+		)
+	}
+
+	return LogScaling{ref: ref, target: target, floor: floor}, nil
+}
+
+func (s LogScaling) Scale(value MeasureValue) MeasureValue {
+	return s.target * MeasureValue(
+		math.Log1p(float64(value/s.floor))/
+			math.Log1p(float64(s.ref/s.floor)),
+	)
+}
+
+func (s LogScaling) Inverse(scaled MeasureValue) MeasureValue {
+	return s.floor * MeasureValue(math.Expm1(
+		float64(scaled/s.target)*math.Log1p(float64(s.ref/s.floor)),
+	))
+}
+
+// SqrtScaling is a milder compression than LogScaling:
+//
+//	scaled = target * sqrt(value / ref)
+//	value  = ref * (scaled / target)^2
+type SqrtScaling struct {
+	ref    MeasureValue
+	target MeasureValue
+}
+
+func NewSqrtScaling(ref, target MeasureValue) SqrtScaling {
+	return SqrtScaling{ref: ref, target: target}
+}
+
+func (s SqrtScaling) Scale(value MeasureValue) MeasureValue {
+	return s.target * MeasureValue(math.Sqrt(float64(value/s.ref)))
+}
+
+func (s SqrtScaling) Inverse(scaled MeasureValue) MeasureValue {
+	ratio := scaled / s.target
+	return s.ref * ratio * ratio
+}
+
+// Anchor pins a value to the scaled value it must map onto.
+type Anchor struct {
+	Value  MeasureValue
+	Scaled MeasureValue
+}
+
+// PiecewiseScaling interpolates linearly between anchors sorted by value, with
+// an implicit anchor at zero. Values beyond the last anchor follow the last
+// segment. Inverse interpolates the same segments the other way round.
+type PiecewiseScaling struct {
+	anchors []Anchor
+}
+
+func NewPiecewiseScaling(anchors ...Anchor) (PiecewiseScaling, error) {
+	sorted := slices.Clone(anchors)
+
+	if !slices.ContainsFunc(sorted, func(a Anchor) bool {
+		return a.Value == 0
+	}) {
+		sorted = append(sorted, Anchor{})
+	}
+
+	slices.SortFunc(sorted, func(a, b Anchor) int {
+		return cmp.Compare(a.Value, b.Value)
+	})
+
+	if len(sorted) < 2 {
+		return PiecewiseScaling{}, fmt.Errorf(
+			"piecewise scaling requires at least one non-zero anchor",
+		)
+	}
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i].Value == sorted[i-1].Value ||
+			sorted[i].Scaled <= sorted[i-1].Scaled {
+			return PiecewiseScaling{}, fmt.Errorf(
+				"piecewise anchors must be strictly increasing, got %g -> %g "+
+					"after %g -> %g",
+				sorted[i].Value,
+				sorted[i].Scaled,
+				sorted[i-1].Value,
+				sorted[i-1].Scaled,
+			)
+		}
+	}
+
+	return PiecewiseScaling{anchors: sorted}, nil
+}
+
+// segment returns the index of the anchor closing the segment containing x,
+// where key selects the anchor coordinate being compared.
+func (s PiecewiseScaling) segment(
+	x MeasureValue,
+	key func(Anchor) MeasureValue,
+) int {
+	i := sort.Search(len(s.anchors), func(i int) bool {
+		return key(s.anchors[i]) >= x
+	})
+
+	return min(max(i, 1), len(s.anchors)-1)
+}
+
+func interpolate(x, x0, x1, y0, y1 MeasureValue) MeasureValue {
+	return y0 + (x-x0)/(x1-x0)*(y1-y0)
+}
+
+func (s PiecewiseScaling) Scale(value MeasureValue) MeasureValue {
+	i := s.segment(value, func(a Anchor) MeasureValue { return a.Value })
+	lo, hi := s.anchors[i-1], s.anchors[i]
+
+	return interpolate(value, lo.Value, hi.Value, lo.Scaled, hi.Scaled)
+}
+
+func (s PiecewiseScaling) Inverse(scaled MeasureValue) MeasureValue {
+	i := s.segment(scaled, func(a Anchor) MeasureValue { return a.Scaled })
+	lo, hi := s.anchors[i-1], s.anchors[i]
+
+	return interpolate(scaled, lo.Scaled, hi.Scaled, lo.Value, hi.Value)
+}
+
+const (
+	ScalingLinear    = "linear"
+	ScalingLog       = "log"
+	ScalingSqrt      = "sqrt"
+	ScalingPiecewise = "piecewise"
+)
+
+// Anchor pins the record with the given label to scaled.
+func (e *Enlistment) Anchor(label string, scaled MeasureValue) (Anchor, error) {
+	for _, rec := range e.records {
+		if rec.label == label {
+			return Anchor{Value: rec.absValue, Scaled: scaled}, nil
+		}
+	}
+
+	return Anchor{}, fmt.Errorf("anchor record '%s' not found", label)
+}
+
+// NewScaling builds the named strategy mapping the reference onto target.
+// The logarithmic floor is the smallest record and piecewise scaling uses
+// anchors together with the reference.
+func (e *Enlistment) NewScaling(
+	kind string,
+	target MeasureValue,
+	anchors ...Anchor,
+) (ScalingStrategy, error) {
+	ref := e.ref.absValue
+
+	switch kind {
+	case ScalingLinear:
+		return NewLinearScaling(ref, target), nil
+	case ScalingLog:
+		return NewLogScaling(ref, target, e.records.minValue())
+	case ScalingSqrt:
+		return NewSqrtScaling(ref, target), nil
+	case ScalingPiecewise:
+		if !slices.ContainsFunc(anchors, func(a Anchor) bool {
+			return a.Value == ref
+		}) {
+			anchors = append(anchors, Anchor{Value: ref, Scaled: target})
+		}
+
+		return NewPiecewiseScaling(anchors...)
+	default:
+		return nil, fmt.Errorf("unknown scaling strategy '%s'", kind)
+	}
+}
@@ -0,0 +1,129 @@
+package refscaler
+
+import (
+	"math"
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+func helperAlmostEqual(a, b MeasureValue) bool {
+	return math.Abs(float64(a-b)) <= 1e-9*math.Max(1, math.Abs(float64(b)))
+}
+
+func TestScalingStrategies(t *testing.T) {
+	logScaling, err := NewLogScaling(1000, 10, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	piecewise, err := NewPiecewiseScaling(
+		Anchor{Value: 10, Scaled: 5},
+		Anchor{Value: 1000, Scaled: 10},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		strategy ScalingStrategy
+		value    MeasureValue
+		expected MeasureValue
+	}{
+		{"linear", NewLinearScaling(1000, 10), 250, 2.5},
+		{"linear reference", NewLinearScaling(1000, 10), 1000, 10},
+		{"log reference", logScaling, 1000, 10},
+		{
+			"log floor",
+			logScaling,
+			1,
+			MeasureValue(10 * math.Ln2 / math.Log(1001)),
+		},
+		{"sqrt", NewSqrtScaling(1000, 10), 250, 5},
+		{"piecewise first segment", piecewise, 4, 2},
+		{"piecewise second segment", piecewise, 505, 7.5},
+		{"piecewise extrapolated", piecewise, 1990, 15},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			scaled := tc.strategy.Scale(tc.value)
+			if !helperAlmostEqual(scaled, tc.expected) {
+				t.Fatalf("expected scaled %g, got %g", tc.expected, scaled)
+			}
+
+			if value := tc.strategy.Inverse(scaled); !helperAlmostEqual(
+				value,
+				tc.value,
+			) {
+				t.Fatalf("expected inverse %g, got %g", tc.value, value)
+			}
+		})
+	}
+}
+
+func TestScalingStrategiesInvalid(t *testing.T) {
+	if _, err := NewLogScaling(1000, 10, 0); err == nil {
+		t.Fatal("expected error for zero logarithmic floor")
+	}
+
+	if _, err := NewPiecewiseScaling(); err == nil {
+		t.Fatal("expected error for piecewise scaling without anchors")
+	}
+
+	if _, err := NewPiecewiseScaling(
+		Anchor{Value: 10, Scaled: 5},
+		Anchor{Value: 20, Scaled: 5},
+	); err == nil {
+		t.Fatal("expected error for non-increasing piecewise anchors")
+	}
+}
+
+func TestEnlistmentNewScaling(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"standard",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	anchor, err := enlistment.Anchor("Item 3", 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, kind := range []string{
+		ScalingLinear,
+		ScalingLog,
+		ScalingSqrt,
+		ScalingPiecewise,
+	} {
+		t.Run(kind, func(t *testing.T) {
+			strategy, err := enlistment.NewScaling(kind, 86_400, anchor)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			scaled := enlistment.GetScaledWith(strategy, enlistment.group)
+
+			if !helperAlmostEqual(scaled.ref.absValue, 86_400) {
+				t.Fatalf(
+					"expected reference scaled to 86400, got %g",
+					scaled.ref.absValue,
+				)
+			}
+		})
+	}
+
+	if _, err := enlistment.NewScaling("cubic", 86_400); err == nil {
+		t.Fatal("expected error for unknown scaling strategy")
+	}
+
+	if _, err := enlistment.Anchor("Item 9", 60); err == nil {
+		t.Fatal("expected error for unknown anchor record")
+	}
+}
@@ -0,0 +1,107 @@
+package refscaler
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+// Value stores the measure as a float in base units.
+func (m MeasureValue) Value() (driver.Value, error) {
+	return float64(m), nil
+}
+
+// Scan reads a number in base units or text accepted by UnmarshalText.
+func (m *MeasureValue) Scan(src any) error {
+	switch v := src.(type) {
+	case float64:
+		*m = MeasureValue(v)
+	case int64:
+		*m = MeasureValue(v)
+	case string:
+		return m.UnmarshalText([]byte(v))
+	case []byte:
+		return m.UnmarshalText(v)
+	case nil:
+		return fmt.Errorf("cannot scan NULL into measure value")
+	default:
+		return fmt.Errorf("cannot scan %T into measure value", src)
+	}
+
+	return nil
+}
+
+// SQLMeasure stores a measure either as a float in base units, by default
+// like MeasureValue, or as its canonical text, e.g. "5400 second", using the
+// base unit of Group.
+//
+// Scan accepts both formats. Text is parsed within Group when it is set,
+// otherwise the group is resolved from units.DecodingRegistry.
+type SQLMeasure struct {
+	Measure MeasureValue
+	Group   *units.UnitGroup
+	Format  units.SQLFormat
+}
+
+func (s SQLMeasure) Value() (driver.Value, error) {
+	switch s.Format {
+	case units.SQLFormatBase:
+		return s.Measure.Value()
+	case units.SQLFormatText:
+		if s.Group == nil {
+			return nil, fmt.Errorf(
+				"cannot store measure as text without a unit group",
+			)
+		}
+
+		base, ok := s.Group.Base()
+		if !ok {
+			return nil, fmt.Errorf(
+				"unit group '%s' has no base unit",
+				s.Group.Name(),
+			)
+		}
+
+		return units.NewQuantity(float64(s.Measure), base).String(), nil
+	default:
+		return nil, fmt.Errorf("unknown sql format %d", s.Format)
+	}
+}
+
+func (s *SQLMeasure) Scan(src any) error {
+	var text string
+
+	switch v := src.(type) {
+	case string:
+		text = v
+	case []byte:
+		text = string(v)
+	default:
+		return s.Measure.Scan(src)
+	}
+
+	var (
+		quantity units.Quantity
+		err      error
+	)
+
+	if s.Group != nil {
+		quantity, err = s.Group.ParseQuantity(text)
+	} else {
+		quantity, err = units.ParseQuantity(text, units.DecodingRegistry())
+	}
+
+	if err != nil {
+		return fmt.Errorf(
+			"failed to create measure value from '%s': %w",
+			text,
+			err,
+		)
+	}
+
+	s.Measure = MeasureValue(quantity.Base())
+	s.Group = quantity.Unit.Group()
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package refscaler
+
+import (
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+func TestMeasureValueSQL(t *testing.T) {
+	db, err := internal.OpenFakeSQL(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	for _, value := range []any{MeasureValue(5400), "1 hour, 30 minutes"} {
+		_, err := db.Exec("INSERT INTO measures VALUES (?)", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rows, err := db.Query("SELECT value")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var measure MeasureValue
+		if err := rows.Scan(&measure); err != nil {
+			t.Fatal(err)
+		}
+
+		if measure != 5400 {
+			t.Errorf("row %d: scanned %v, want 5400", count, measure)
+		}
+
+		count++
+	}
+
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 2 {
+		t.Errorf("scanned %d rows, want 2", count)
+	}
+}
+
+func TestSQLMeasureFormats(t *testing.T) {
+	group, _ := units.EmbeddedUnitRegistry.Get("time")
+
+	testCases := []struct {
+		name    string
+		format  units.SQLFormat
+		measure MeasureValue
+		stored  any
+	}{
+		{
+			name:    "text",
+			format:  units.SQLFormatText,
+			measure: 5400,
+			stored:  "5400 second",
+		},
+		{
+			name:    "text zero",
+			format:  units.SQLFormatText,
+			measure: 0,
+			stored:  "0 second",
+		},
+		{
+			name:    "base",
+			format:  units.SQLFormatBase,
+			measure: 5400,
+			stored:  5400.0,
+		},
+		// the zero value stores floats like MeasureValue
+		{name: "default", measure: 5400, stored: 5400.0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := internal.OpenFakeSQL(t.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			_, err = db.Exec(
+				"INSERT INTO measures VALUES (?)",
+				SQLMeasure{
+					Measure: tc.measure,
+					Group:   group,
+					Format:  tc.format,
+				},
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var raw any
+			if err := db.QueryRow("SELECT value").Scan(&raw); err != nil {
+				t.Fatal(err)
+			}
+
+			if raw != tc.stored {
+				t.Errorf("stored %#v, want %#v", raw, tc.stored)
+			}
+
+			// text is parsed within the group or resolved from the registry
+			for _, scanned := range []SQLMeasure{{}, {Group: group}} {
+				err := db.QueryRow("SELECT value").Scan(&scanned)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if scanned.Measure != tc.measure {
+					t.Errorf("scanned %v, want %v", scanned.Measure, tc.measure)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLMeasureErrors(t *testing.T) {
+	_, err := SQLMeasure{Measure: 1, Format: units.SQLFormatText}.Value()
+	if err == nil {
+		t.Error("Value() as text without a group expected error")
+	}
+
+	group, _ := units.EmbeddedUnitRegistry.Get("time")
+	scanned := SQLMeasure{Group: group}
+
+	if err := scanned.Scan("5 meters"); err == nil {
+		t.Error("Scan() accepted alias outside of the group")
+	}
+
+	if err := scanned.Scan(nil); err == nil {
+		t.Error("Scan(nil) expected error")
+	}
+}
@@ -0,0 +1,114 @@
+package refscaler
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+// TimelineDirection tells how record values of a timeline enlistment are
+// measured.
+type TimelineDirection int
+
+const (
+	// TimelineAgo treats values as offsets back from the present, e.g.
+	// "Dinosaurs extinct: 66e6 years". The reference is the oldest event.
+	TimelineAgo TimelineDirection = iota
+	// TimelineSince treats values as offsets forward from an origin, e.g.
+	// "First cells: 1e9 years" counted from the formation of Earth.
+	TimelineSince
+)
+
+// CalendarLayout is the default time.Layout used by Timeline.ToCalendar.
+const CalendarLayout = "January 2, 15:04"
+
+const secondsPerDay = 24 * 60 * 60
+
+// addSeconds adds whole days with AddDate and only the remainder as a
+// time.Duration, which overflows past about 292 years.
+func addSeconds(start time.Time, seconds float64) time.Time {
+	days := math.Floor(seconds / secondsPerDay)
+	remainder := seconds - days*secondsPerDay
+
+	return start.AddDate(0, 0, int(days)).
+		Add(time.Duration(remainder * float64(time.Second)))
+}
+
+// Timeline holds events positioned along a scaled span. Each event is stored
+// as a Record whose value is the distance from the start of the span.
+type Timeline struct {
+	events RecordSlice
+	group  *units.UnitGroup
+	span   MeasureValue
+}
+
+// GetTimeline maps the interval between the reference record and the present
+// (TimelineAgo) or the origin (TimelineSince) onto span, given in base units
+// of group, and returns events in chronological order.
+func (e *Enlistment) GetTimeline(
+	span MeasureValue,
+	group *units.UnitGroup,
+	direction TimelineDirection,
+) *Timeline {
+	events := make(RecordSlice, 0, len(e.records))
+
+	for _, rec := range e.records {
+		offset := rec.absValue
+		if direction == TimelineAgo {
+			offset = e.ref.absValue - rec.absValue
+		}
+
+		events = append(events, &Record{
+			label:    rec.label,
+			absValue: offset / e.ref.absValue * span,
+			position: rec.position,
+			group:    group,
+		})
+	}
+
+	slices.SortStableFunc(events, func(a, b *Record) int {
+		return cmp.Compare(a.absValue, b.absValue)
+	})
+
+	return &Timeline{
+		events: events,
+		group:  group,
+		span:   span,
+	}
+}
+
+func (t *Timeline) Length() int {
+	return len(t.events)
+}
+
+// ToString formats each event as its distance from the start of the span.
+func (t *Timeline) ToString(num_units int) []string {
+	return t.events.toString(num_units, t.group)
+}
+
+// ToCalendar formats each event as a point in time counted from start. The
+// timeline group must measure time in seconds.
+func (t *Timeline) ToCalendar(
+	start time.Time,
+	layout string,
+) ([]string, error) {
+	if unit, ok := t.group.Get("second"); !ok || unit.Multiplier != 1.0 {
+		return nil, fmt.Errorf("calendar positions require a time unit group")
+	}
+
+	result := make([]string, 0, len(t.events))
+
+	for _, event := range t.events {
+		result = append(result, fmt.Sprintf(
+			"%s: %s",
+			event.label,
+			addSeconds(start, float64(event.absValue)).Format(layout),
+		))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,173 @@
+package refscaler
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units"
+)
+
+func TestEnlistmentGetTimeline(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"timeline",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span, group, err := enlistment.MakeScale(
+		"138 meters",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name      string
+		direction TimelineDirection
+		expected  []internal.TestEnlistment
+	}{
+		{
+			name:      "ago",
+			direction: TimelineAgo,
+			expected: []internal.TestEnlistment{
+				{Label: "Big Bang", Value: 0},
+				{Label: "Earth forms", Value: 92.6},
+				{Label: "Dinosaurs extinct", Value: 137.34},
+				{Label: "Humans", Value: 137.997},
+			},
+		},
+		{
+			name:      "since",
+			direction: TimelineSince,
+			expected: []internal.TestEnlistment{
+				{Label: "Humans", Value: 0.003},
+				{Label: "Dinosaurs extinct", Value: 0.66},
+				{Label: "Earth forms", Value: 45.4},
+				{Label: "Big Bang", Value: 138},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			timeline := enlistment.GetTimeline(span, group, tc.direction)
+
+			if timeline.Length() != len(tc.expected) {
+				t.Fatalf(
+					"expected %d events, got %d",
+					len(tc.expected),
+					timeline.Length(),
+				)
+			}
+
+			for exp, event := range internal.IterZip(
+				tc.expected,
+				timeline.events,
+			) {
+				if event.label != exp.Label {
+					t.Fatalf(
+						"expected label '%s', got '%s'",
+						exp.Label,
+						event.label,
+					)
+				}
+
+				if math.Abs(float64(event.absValue)-exp.Value) > 1e-9 {
+					t.Fatalf(
+						"expected position %f for '%s', got %f",
+						exp.Value,
+						exp.Label,
+						event.absValue,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestTimelineToCalendar(t *testing.T) {
+	enlistment, err := NewEnlistmentFromFile(
+		internal.GetFixtureEnlistmentFs(),
+		"timeline",
+		units.EmbeddedUnitRegistry,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	span, err := enlistment.MakeMeasureValue("1 year")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	calendar, err := enlistment.GetTimeline(
+		span,
+		enlistment.group,
+		TimelineAgo,
+	).ToCalendar(start, CalendarLayout)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"Big Bang: January 1, 00:00",
+		"Earth forms: September 2, 22:05",
+		"Dinosaurs extinct: December 30, 06:06",
+		"Humans: December 31, 23:48",
+	}
+
+	for exp, res := range internal.IterZip(expected, calendar) {
+		if exp != res {
+			t.Fatalf("expected '%s' got '%s'", exp, res)
+		}
+	}
+
+	span, err = enlistment.MakeMeasureValue("1000 year")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	calendar, err = enlistment.GetTimeline(
+		span,
+		enlistment.group,
+		TimelineAgo,
+	).ToCalendar(start, "2006-01-02 15:04")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// spans beyond the range of time.Duration
+	expected = []string{
+		"Big Bang: 2001-01-01 00:00",
+		"Earth forms: 2671-07-28 06:57",
+		"Dinosaurs extinct: 2995-07-23 08:20",
+		"Humans: 3000-04-26 01:33",
+	}
+
+	for exp, res := range internal.IterZip(expected, calendar) {
+		if exp != res {
+			t.Fatalf("expected '%s' got '%s'", exp, res)
+		}
+	}
+
+	distance, group, _ := enlistment.MakeScale(
+		"1 kilometer",
+		units.EmbeddedUnitRegistry,
+	)
+
+	if _, err := enlistment.GetTimeline(
+		distance,
+		group,
+		TimelineAgo,
+	).ToCalendar(start, CalendarLayout); err == nil {
+		t.Fatal("expected error for calendar of a length timeline")
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+// addUnitSourceFlags registers the repeatable -units-dir and -units-replace
+// flags, collecting directories in command-line order.
+func addUnitSourceFlags(flags *flag.FlagSet, sources *[]units.UnitSource) {
+	flags.Func(
+		"units-dir",
+		"directory of unit files extending the built-in groups (repeatable)",
+		func(path string) error {
+			*sources = append(*sources, units.UnitSource{Path: path})
+			return nil
+		},
+	)
+	flags.Func(
+		"units-replace",
+		"directory of unit files replacing groups of the same name "+
+			"(repeatable)",
+		func(path string) error {
+			*sources = append(
+				*sources,
+				units.UnitSource{Path: path, Replace: true},
+			)
+			return nil
+		},
+	)
+}
+
+// loadRegistry merges the built-in units with the user config directory,
+// units.UnitsPathEnv and sources, in this order. Conflicts are printed to
+// stderr as warnings.
+func loadRegistry(
+	sources []units.UnitSource,
+	stderr io.Writer,
+) (units.UnitRegistry, error) {
+	embedded, err := units.DefaultRegistry()
+	if err != nil {
+		return nil, err
+	}
+
+	registry, conflicts, err := units.LoadRegistry(
+		embedded,
+		append(units.UserUnitSources(), sources...)...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, conflict := range conflicts {
+		fmt.Fprintf(stderr, "refscaler: warning: %s\n", conflict)
+	}
+
+	return units.NewIndexedRegistry(&registry), nil
+}
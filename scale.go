@@ -0,0 +1,452 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/grzadr/refscaler/refscaler"
+	"github.com/grzadr/refscaler/units"
+)
+
+const defaultNumUnits = 3
+
+type scaleOptions struct {
+	scale     string
+	reference string
+	numUnits  int
+	sortOrder string
+	document  bool
+	stats     bool
+	allow     string
+	deny      string
+	unit      string
+	systems   string
+	bestFit   bool
+	remainder bool
+	fitMin    float64
+	fitMax    float64
+	exact     bool
+	dupes     string
+	strategy  string
+	anchors   []string
+	unitDirs  []units.UnitSource
+	path      string
+}
+
+func parseScaleOptions(
+	args []string,
+	stderr io.Writer,
+) (opts scaleOptions, err error) {
+	flags := flag.NewFlagSet("scale", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: refscaler scale [flags] <file|->")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(
+		&opts.scale,
+		"scale",
+		"",
+		"scaled reference value, any unit group (overrides '#! scale')",
+	)
+	flags.StringVar(
+		&opts.reference,
+		"reference",
+		"",
+		"label of the reference record (overrides '#! reference')",
+	)
+	addNumUnitsFlag(
+		flags,
+		&opts.numUnits,
+		"number of units used to print each value (overrides '#! units')",
+	)
+
+	flags.StringVar(
+		&opts.sortOrder,
+		"sort",
+		refscaler.SortByValue.String(),
+		"order of printed records: value, label or input",
+	)
+	flags.BoolVar(
+		&opts.document,
+		"document",
+		false,
+		"print a scaled copy of the file keeping its comments and layout",
+	)
+
+	flags.BoolVar(
+		&opts.stats,
+		"stats",
+		false,
+		"print relative figures of every record and summary statistics",
+	)
+	flags.StringVar(
+		&opts.allow,
+		"allow",
+		"",
+		"comma-separated units allowed in the output, e.g. 'day,hour'",
+	)
+	flags.StringVar(
+		&opts.deny,
+		"deny",
+		"",
+		"comma-separated units never used in the output",
+	)
+	flags.StringVar(
+		&opts.unit,
+		"unit",
+		"",
+		"print every value in this single unit",
+	)
+	flags.StringVar(
+		&opts.systems,
+		"system",
+		"",
+		"comma-separated unit systems used in the output, e.g. 'metric'",
+	)
+	flags.BoolVar(
+		&opts.bestFit,
+		"best-fit",
+		false,
+		"choose the most readable units for every value separately",
+	)
+	flags.Float64Var(
+		&opts.fitMin,
+		"fit-min",
+		refscaler.DefaultFitMin,
+		"smallest count of the leading unit with -best-fit",
+	)
+	flags.Float64Var(
+		&opts.fitMax,
+		"fit-max",
+		refscaler.DefaultFitMax,
+		"count of the leading unit -best-fit stays below",
+	)
+	flags.BoolVar(
+		&opts.remainder,
+		"remainder",
+		false,
+		"print what the smallest unit cannot take as a fraction of it",
+	)
+	flags.BoolVar(
+		&opts.exact,
+		"exact",
+		false,
+		"use exact rational arithmetic (linear scaling only)",
+	)
+	flags.StringVar(
+		&opts.dupes,
+		"duplicates",
+		refscaler.DuplicateKeep.String(),
+		"repeated labels: keep, error, first, last, sum or suffix",
+	)
+	flags.StringVar(
+		&opts.strategy,
+		"strategy",
+		refscaler.ScalingLinear,
+		"scaling strategy: linear, log, sqrt or piecewise",
+	)
+	flags.Func(
+		"anchor",
+		"'label=value' pinning a record for piecewise scaling (repeatable)",
+		func(anchor string) error {
+			opts.anchors = append(opts.anchors, anchor)
+			return nil
+		},
+	)
+
+	addUnitSourceFlags(flags, &opts.unitDirs)
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if !opts.bestFit &&
+		(isFlagSet(flags, "fit-min") || isFlagSet(flags, "fit-max")) {
+		return opts, fmt.Errorf("-fit-min and -fit-max require -best-fit")
+	}
+
+	if opts.document && isFlagSet(flags, "sort") {
+		return opts, fmt.Errorf(
+			"-sort cannot be used with -document, which keeps the file order",
+		)
+	}
+
+	if opts.document && opts.stats {
+		return opts, fmt.Errorf(
+			"-stats cannot be used with -document, which keeps the file layout",
+		)
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return opts, fmt.Errorf("expected exactly one enlistment file")
+	}
+
+	opts.path = flags.Arg(0)
+
+	return opts, nil
+}
+
+// isFlagSet reports whether the flag name was given on the command line.
+func isFlagSet(flags *flag.FlagSet, name string) (set bool) {
+	flags.Visit(func(f *flag.Flag) {
+		set = set || f.Name == name
+	})
+
+	return
+}
+
+// addNumUnitsFlag registers -units, accepting positive integers only. The
+// target stays 0 when the flag is absent so directives can fill it in.
+func addNumUnitsFlag(flags *flag.FlagSet, target *int, usage string) {
+	flags.Func("units", usage, func(value string) error {
+		num, err := strconv.Atoi(value)
+		if err != nil || num <= 0 {
+			return fmt.Errorf("requires a positive integer, got '%s'", value)
+		}
+
+		*target = num
+
+		return nil
+	})
+}
+
+func splitList(list string) []string {
+	if len(list) == 0 {
+		return nil
+	}
+
+	items := strings.Split(list, ",")
+	for i := range items {
+		items[i] = strings.TrimSpace(items[i])
+	}
+
+	return items
+}
+
+func (o *scaleOptions) formatOptions() refscaler.FormatOptions {
+	return refscaler.FormatOptions{
+		NumUnits:  o.numUnits,
+		Allow:     splitList(o.allow),
+		Deny:      splitList(o.deny),
+		Unit:      o.unit,
+		Systems:   splitList(o.systems),
+		BestFit:   o.bestFit,
+		FitMin:    o.fitMin,
+		FitMax:    o.fitMax,
+		Remainder: o.remainder,
+	}
+}
+
+// withDirectives fills options not given on the command line with the
+// defaults declared in the enlistment file.
+func (o *scaleOptions) withDirectives(directives refscaler.Directives) {
+	if len(o.scale) == 0 {
+		o.scale = directives.Scale
+	}
+
+	if len(o.reference) == 0 {
+		o.reference = directives.Reference
+	}
+
+	if o.numUnits == 0 {
+		o.numUnits = directives.Units
+	}
+
+	if o.numUnits == 0 {
+		o.numUnits = defaultNumUnits
+	}
+}
+
+func loadEnlistment(
+	path string,
+	registry units.UnitRegistry,
+	opts refscaler.LoadOptions,
+) (enlistment *refscaler.Enlistment, err error) {
+	if path == "-" {
+		return refscaler.NewEnlistmentWith(os.Stdin, registry, opts)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		closeErr := file.Close()
+		if err == nil && closeErr != nil {
+			err = closeErr
+		}
+	}()
+
+	return refscaler.NewEnlistmentWith(file, registry, opts)
+}
+
+func makeAnchors(
+	enlistment *refscaler.Enlistment,
+	registry units.UnitRegistry,
+	group *units.UnitGroup,
+	anchors []string,
+) ([]refscaler.Anchor, error) {
+	result := make([]refscaler.Anchor, 0, len(anchors))
+
+	for _, anchor := range anchors {
+		label, measure, found := strings.Cut(anchor, "=")
+		if !found {
+			return nil, fmt.Errorf(
+				"anchor '%s' must have the form 'label=value'",
+				anchor,
+			)
+		}
+
+		scaled, anchorGroup, err := enlistment.MakeScale(
+			measure,
+			registry,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if anchorGroup != group {
+			return nil, fmt.Errorf(
+				"anchor '%s' must use the units of the scale",
+				anchor,
+			)
+		}
+
+		a, err := enlistment.Anchor(label, scaled)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, a)
+	}
+
+	return result, nil
+}
+
+func scaleEnlistment(
+	enlistment *refscaler.Enlistment,
+	registry units.UnitRegistry,
+	opts scaleOptions,
+) (*refscaler.Enlistment, error) {
+	if opts.exact {
+		if opts.strategy != refscaler.ScalingLinear {
+			return nil, fmt.Errorf("-exact supports only linear scaling")
+		}
+
+		scale, group, err := enlistment.MakeExactScale(
+			opts.scale,
+			registry,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		return enlistment.GetScaledExact(scale, group)
+	}
+
+	scale, group, err := enlistment.MakeScale(
+		opts.scale,
+		registry,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	anchors, err := makeAnchors(enlistment, registry, group, opts.anchors)
+	if err != nil {
+		return nil, err
+	}
+
+	strategy, err := enlistment.NewScaling(opts.strategy, scale, anchors...)
+	if err != nil {
+		return nil, err
+	}
+
+	return enlistment.GetScaledWith(strategy, group), nil
+}
+
+func runScale(args []string, stdout, stderr io.Writer) error {
+	opts, err := parseScaleOptions(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	order, err := refscaler.ParseSortOrder(opts.sortOrder)
+	if err != nil {
+		return err
+	}
+
+	duplicates, err := refscaler.ParseDuplicatePolicy(opts.dupes)
+	if err != nil {
+		return err
+	}
+
+	registry, err := loadRegistry(opts.unitDirs, stderr)
+	if err != nil {
+		return err
+	}
+
+	enlistment, err := loadEnlistment(
+		opts.path,
+		registry,
+		refscaler.LoadOptions{
+			Exact:      opts.exact,
+			Duplicates: duplicates,
+			Reference:  opts.reference,
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	opts.withDirectives(enlistment.Directives())
+
+	if len(opts.scale) == 0 {
+		return fmt.Errorf(
+			"no scale given, use -scale or a '#! scale' directive",
+		)
+	}
+
+	scaled, err := scaleEnlistment(enlistment, registry, opts)
+	if err != nil {
+		return err
+	}
+
+	if opts.document {
+		return scaled.WriteDocumentWith(stdout, opts.formatOptions())
+	}
+
+	if err := scaled.SortBy(order); err != nil {
+		return err
+	}
+
+	lines, err := scaled.ToStringWith(opts.formatOptions())
+	if err != nil {
+		return err
+	}
+
+	if opts.stats {
+		analysis, err := scaled.Analyze()
+		if err != nil {
+			return err
+		}
+
+		lines, err = analysis.ToStringWith(opts.formatOptions())
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(stdout, line)
+	}
+
+	return nil
+}
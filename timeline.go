@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/grzadr/refscaler/refscaler"
+	"github.com/grzadr/refscaler/units"
+)
+
+type timelineOptions struct {
+	span     string
+	numUnits int
+	since    bool
+	calendar bool
+	layout   string
+	unitDirs []units.UnitSource
+	path     string
+}
+
+func parseTimelineOptions(
+	args []string,
+	stderr io.Writer,
+) (opts timelineOptions, err error) {
+	flags := flag.NewFlagSet("timeline", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: refscaler timeline [flags] <file|->")
+		flags.PrintDefaults()
+	}
+
+	flags.StringVar(
+		&opts.span,
+		"span",
+		"",
+		"span the whole timeline is mapped onto (overrides '#! scale')",
+	)
+	addNumUnitsFlag(
+		flags,
+		&opts.numUnits,
+		"number of units used to print each position (overrides '#! units')",
+	)
+	flags.BoolVar(
+		&opts.since,
+		"since",
+		false,
+		"values count forward from the origin instead of back from now",
+	)
+	flags.BoolVar(
+		&opts.calendar,
+		"calendar",
+		false,
+		"print positions as calendar dates counted from January 1",
+	)
+	flags.StringVar(
+		&opts.layout,
+		"layout",
+		refscaler.CalendarLayout,
+		"Go time layout used with -calendar",
+	)
+
+	addUnitSourceFlags(flags, &opts.unitDirs)
+
+	if err := flags.Parse(args); err != nil {
+		return opts, err
+	}
+
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return opts, fmt.Errorf("expected exactly one enlistment file")
+	}
+
+	opts.path = flags.Arg(0)
+
+	return opts, nil
+}
+
+func runTimeline(args []string, stdout, stderr io.Writer) error {
+	opts, err := parseTimelineOptions(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	registry, err := loadRegistry(opts.unitDirs, stderr)
+	if err != nil {
+		return err
+	}
+
+	enlistment, err := loadEnlistment(
+		opts.path,
+		registry,
+		refscaler.LoadOptions{},
+	)
+	if err != nil {
+		return err
+	}
+
+	directives := enlistment.Directives()
+
+	if len(opts.span) == 0 {
+		opts.span = directives.Scale
+	}
+
+	if len(opts.span) == 0 {
+		return fmt.Errorf("no span given, use -span or a '#! scale' directive")
+	}
+
+	if opts.numUnits == 0 {
+		opts.numUnits = directives.Units
+	}
+
+	if opts.numUnits == 0 {
+		opts.numUnits = defaultNumUnits
+	}
+
+	span, group, err := enlistment.MakeScale(opts.span, registry)
+	if err != nil {
+		return err
+	}
+
+	direction := refscaler.TimelineAgo
+	if opts.since {
+		direction = refscaler.TimelineSince
+	}
+
+	timeline := enlistment.GetTimeline(span, group, direction)
+
+	lines := timeline.ToString(opts.numUnits)
+
+	if opts.calendar {
+		// 2001 is not a leap year, matching the 365 day year unit.
+		start := time.Date(2001, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+		if lines, err = timeline.ToCalendar(start, opts.layout); err != nil {
+			return err
+		}
+	}
+
+	for _, line := range lines {
+		fmt.Fprintln(stdout, line)
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/grzadr/refscaler/units"
+)
+
+const unitsUsage = `Usage: refscaler units <command> [flags] [arguments]
+
+Commands:
+  check       validate a directory of unit files, or the merged units used by
+              the other commands when no directory is given
+  schema      print the JSON Schema of unit files
+`
+
+func runUnits(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, unitsUsage)
+		return fmt.Errorf("missing units command")
+	}
+
+	switch args[0] {
+	case "check":
+		return runUnitsCheck(args[1:], stdout, stderr)
+	case "schema":
+		_, err := stdout.Write(units.UnitFileSchema)
+		return err
+	default:
+		fmt.Fprint(stderr, unitsUsage)
+		return fmt.Errorf("unknown units command '%s'", args[0])
+	}
+}
+
+func runUnitsCheck(args []string, stdout, stderr io.Writer) error {
+	flags := flag.NewFlagSet("units check", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: refscaler units check [flags] [dir]")
+		flags.PrintDefaults()
+	}
+
+	strict := flags.Bool("strict", false, "fail on warnings as well")
+
+	var sources []units.UnitSource
+	addUnitSourceFlags(flags, &sources)
+
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return fmt.Errorf("expected at most one units directory")
+	}
+
+	var registry units.UnitRegistry
+
+	// A directory is checked on its own, without the merged unit sources.
+	if flags.NArg() == 1 {
+		if len(sources) > 0 {
+			flags.Usage()
+			return fmt.Errorf(
+				"-units-dir and -units-replace cannot be used with a " +
+					"units directory",
+			)
+		}
+
+		files, err := units.NewUnitRegistryFiles(os.DirFS(flags.Arg(0)), ".")
+		if err != nil {
+			return fmt.Errorf(
+				"failed to load units from '%s': %w",
+				flags.Arg(0),
+				err,
+			)
+		}
+
+		registry = &files
+	} else {
+		merged, err := loadRegistry(sources, stderr)
+		if err != nil {
+			return err
+		}
+
+		registry = merged
+	}
+
+	report := units.Validate(registry)
+	errors := 0
+
+	for _, issue := range report.Issues {
+		fmt.Fprintln(stdout, issue)
+
+		if issue.Severity == units.SeverityError {
+			errors++
+		}
+	}
+
+	warnings := len(report.Issues) - errors
+	fmt.Fprintf(stdout, "%d errors, %d warnings\n", errors, warnings)
+
+	if errors > 0 || (*strict && warnings > 0) {
+		return fmt.Errorf("unit database check failed")
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package units
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grzadr/refscaler/units/unit_entry"
+)
+
+type definitionState int
+
+const (
+	definitionPending definitionState = iota
+	definitionResolving
+	definitionResolved
+)
+
+// definitionResolver computes the values of entries given by a definition
+// such as "12 inch" from the other entries of the same file.
+type definitionResolver struct {
+	entries []unit_entry.UnitEntry
+	exact   []*big.Rat       // multipliers of resolved entries
+	byAlias map[string][]int // entries with the alias, in file order
+	states  []definitionState
+	path    []string // names being resolved, to report cycles
+}
+
+// decimalRat returns the shortest decimal representing value, which is the
+// number as written in the unit file for up to 15 significant digits.
+func decimalRat(value float64) *big.Rat {
+	rat, _ := new(big.Rat).SetString(strconv.FormatFloat(value, 'g', -1, 64))
+	return rat
+}
+
+// resolveDefinitions sets Value of every entry with a Definition, resolving
+// referenced entries first, and returns the exact multiplier of every entry.
+// Definitions are summed in rational arithmetic, so "12 inch" of 0.0254 is
+// exactly 0.3048. It fails on references to units not defined in entries or
+// to aliases shared by several entries, and on cyclic definitions.
+func resolveDefinitions(entries []unit_entry.UnitEntry) ([]*big.Rat, error) {
+	resolver := definitionResolver{
+		entries: entries,
+		exact:   make([]*big.Rat, len(entries)),
+		byAlias: make(map[string][]int, len(entries)*4),
+		states:  make([]definitionState, len(entries)),
+	}
+
+	for i, entry := range entries {
+		aliases := append([]string{entry.Name}, entry.Aliases...)
+		aliases = append(aliases, entry.Symbol, entry.Plural)
+
+		for _, alias := range aliases {
+			if len(alias) == 0 {
+				continue
+			}
+
+			alias = NormalizeAlias(alias)
+			if !slices.Contains(resolver.byAlias[alias], i) {
+				resolver.byAlias[alias] = append(resolver.byAlias[alias], i)
+			}
+		}
+	}
+
+	for i := range entries {
+		if err := resolver.resolve(i); err != nil {
+			return nil, err
+		}
+	}
+
+	return resolver.exact, nil
+}
+
+func (r *definitionResolver) resolve(i int) error {
+	entry := &r.entries[i]
+
+	switch r.states[i] {
+	case definitionResolved:
+		return nil
+	case definitionResolving:
+		return fmt.Errorf(
+			"cyclic unit definition: %s -> %s",
+			strings.Join(r.path, " -> "),
+			entry.Name,
+		)
+	}
+
+	if len(entry.Definition) == 0 {
+		r.exact[i] = decimalRat(entry.Value)
+		r.states[i] = definitionResolved
+		return nil
+	}
+
+	r.states[i] = definitionResolving
+	r.path = append(r.path, entry.Name)
+
+	terms, err := ParseTerms(entry.Definition)
+	if err != nil {
+		return fmt.Errorf(
+			"unit '%s' has invalid definition '%s': %w",
+			entry.Name,
+			entry.Definition,
+			err,
+		)
+	}
+
+	value := new(big.Rat)
+
+	for _, term := range terms {
+		refs := r.byAlias[NormalizeAlias(term.Alias)]
+
+		if len(refs) == 0 {
+			return fmt.Errorf(
+				"unit '%s' definition '%s' refers to undefined unit '%s'",
+				entry.Name,
+				entry.Definition,
+				term.Alias,
+			)
+		}
+
+		if len(refs) > 1 {
+			return fmt.Errorf(
+				"unit '%s' definition '%s' refers to alias '%s' "+
+					"shared by units '%s' and '%s'",
+				entry.Name,
+				entry.Definition,
+				term.Alias,
+				r.entries[refs[0]].Name,
+				r.entries[refs[1]].Name,
+			)
+		}
+
+		ref := refs[0]
+
+		if err := r.resolve(ref); err != nil {
+			return err
+		}
+
+		count, ok := new(big.Rat).SetString(term.Text)
+		if !ok {
+			count = decimalRat(term.Value)
+		}
+
+		value.Add(value, count.Mul(count, r.exact[ref]))
+	}
+
+	if value.Sign() <= 0 {
+		return fmt.Errorf(
+			"unit '%s' definition '%s': %w",
+			entry.Name,
+			entry.Definition,
+			unit_entry.ErrZeroValue,
+		)
+	}
+
+	entry.Value, _ = value.Float64()
+	r.exact[i] = value
+	r.path = r.path[:len(r.path)-1]
+	r.states[i] = definitionResolved
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package units
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestNewUnitGroupDefinitions(t *testing.T) {
+	group, err := NewUnitGroup(strings.NewReader(`[
+		{"name": "yard", "definition": "3 foot"},
+		{"name": "foot", "definition": "12 inch", "aliases": ["ft"]},
+		{"name": "inch", "value": 0.025},
+		{"name": "meter", "value": 1},
+		{"name": "span", "definition": "1 ft, 3 inch"}
+	]`))
+	if err != nil {
+		t.Fatalf("NewUnitGroup() error = %v", err)
+	}
+
+	expected := map[string]float64{
+		"inch": 0.025,
+		"foot": 0.3,
+		"yard": 0.9,
+		"span": 0.375,
+	}
+
+	for name, want := range expected {
+		unit, ok := group.Get(name)
+		if !ok {
+			t.Fatalf("Get(%q) found nothing", name)
+		}
+
+		if diff := unit.Multiplier - want; diff > 1e-12 || diff < -1e-12 {
+			t.Errorf("%s value = %v, want %v", name, unit.Multiplier, want)
+		}
+	}
+}
+
+func TestNewUnitGroupDefinitionsExact(t *testing.T) {
+	group, err := NewUnitGroup(strings.NewReader(`[
+		{"name": "meter", "value": 1},
+		{"name": "inch", "value": 0.0254},
+		{"name": "foot", "definition": "12 inch"},
+		{"name": "mile", "definition": "5280 foot"}
+	]`))
+	if err != nil {
+		t.Fatalf("NewUnitGroup() error = %v", err)
+	}
+
+	expected := []struct {
+		name  string
+		value float64
+		exact *big.Rat
+	}{
+		{name: "inch", value: 0.0254, exact: big.NewRat(127, 5000)},
+		{name: "foot", value: 0.3048, exact: big.NewRat(381, 1250)},
+		{name: "mile", value: 1609.344, exact: big.NewRat(201168, 125)},
+	}
+
+	for _, tc := range expected {
+		unit, ok := group.Get(tc.name)
+		if !ok {
+			t.Fatalf("Get(%q) found nothing", tc.name)
+		}
+
+		if unit.Multiplier != tc.value {
+			t.Errorf(
+				"%s value = %v, want %v",
+				tc.name,
+				unit.Multiplier,
+				tc.value,
+			)
+		}
+
+		if exact := unit.ExactMultiplier(); exact.Cmp(tc.exact) != 0 {
+			t.Errorf("%s exact = %v, want %v", tc.name, exact, tc.exact)
+		}
+	}
+}
+
+func TestEmbeddedLengthDefinitions(t *testing.T) {
+	group, _ := EmbeddedUnitRegistry.Get("length")
+
+	expected := map[string]*big.Rat{
+		"foot":    big.NewRat(381, 1250),
+		"yard":    big.NewRat(1143, 1250),
+		"mile":    big.NewRat(201168, 125),
+		"furlong": big.NewRat(25146, 125),
+		"thou":    big.NewRat(127, 5000000),
+	}
+
+	for name, exact := range expected {
+		unit, ok := group.Get(name)
+		if !ok {
+			t.Fatalf("Get(%q) found nothing", name)
+		}
+
+		if unit.ExactMultiplier().Cmp(exact) != 0 {
+			t.Errorf(
+				"%s exact multiplier = %s, want %s",
+				name,
+				unit.ExactMultiplier(),
+				exact,
+			)
+		}
+	}
+}
+
+func TestNewUnitGroupDefinitionErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name: "undefined unit",
+			input: `[
+				{"name": "meter", "value": 1},
+				{"name": "foot", "definition": "12 inch"}
+			]`,
+			wantErr: "unit 'foot' definition '12 inch' " +
+				"refers to undefined unit 'inch'",
+		},
+		{
+			name: "ambiguous alias",
+			input: `[
+				{"name": "meter", "value": 1, "aliases": ["m"]},
+				{"name": "mile", "value": 1609.344, "aliases": ["m"]},
+				{"name": "chain", "definition": "20 m"}
+			]`,
+			wantErr: "unit 'chain' definition '20 m' refers to alias 'm' " +
+				"shared by units 'meter' and 'mile'",
+		},
+		{
+			name: "cycle",
+			input: `[
+				{"name": "meter", "value": 1},
+				{"name": "a", "definition": "2 b"},
+				{"name": "b", "definition": "3 c"},
+				{"name": "c", "definition": "4 a"}
+			]`,
+			wantErr: "cyclic unit definition: a -> b -> c -> a",
+		},
+		{
+			name: "self reference",
+			input: `[
+				{"name": "meter", "value": 1},
+				{"name": "loop", "definition": "1 loop"}
+			]`,
+			wantErr: "cyclic unit definition: loop -> loop",
+		},
+		{
+			name: "invalid definition",
+			input: `[
+				{"name": "meter", "value": 1},
+				{"name": "foot", "definition": "twelve inches"}
+			]`,
+			wantErr: "unit 'foot' has invalid definition 'twelve inches'",
+		},
+		{
+			name: "zero value",
+			input: `[
+				{"name": "meter", "value": 1},
+				{"name": "none", "definition": "0 meter"}
+			]`,
+			wantErr: "unit 'none' definition '0 meter'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewUnitGroup(strings.NewReader(tc.input))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
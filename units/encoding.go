@@ -0,0 +1,108 @@
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+var decoding struct {
+	sync.RWMutex
+	registry UnitRegistry
+}
+
+// SetDecodingRegistry sets the registry resolving unit groups when quantities
+// and measures are decoded from text or JSON. nil restores the indexed
+// embedded registry. It is safe for concurrent use.
+func SetDecodingRegistry(registry UnitRegistry) {
+	decoding.Lock()
+	defer decoding.Unlock()
+
+	decoding.registry = registry
+}
+
+// DecodingRegistry returns the registry set with SetDecodingRegistry or the
+// one from DefaultIndexedRegistry.
+func DecodingRegistry() UnitRegistry {
+	decoding.RLock()
+	defer decoding.RUnlock()
+
+	if decoding.registry != nil {
+		return decoding.registry
+	}
+
+	registry, _ := DefaultIndexedRegistry()
+
+	return registry
+}
+
+// DefaultNumUnits is the number of units used by "%+v" to decompose values.
+const DefaultNumUnits = 3
+
+// ParseQuantity parses a measure such as "1 hour, 30 minutes", resolving the
+// unit group from registry by the first alias.
+func ParseQuantity(measures string, registry UnitRegistry) (Quantity, error) {
+	terms, err := ParseTerms(measures)
+	if err != nil {
+		return Quantity{}, err
+	}
+
+	group, ok := registry.Find(terms[0].Alias)
+	if !ok {
+		return Quantity{}, UnknownAlias(terms[0].Alias, registry)
+	}
+
+	return group.ParseQuantity(measures)
+}
+
+func (q Quantity) MarshalText() ([]byte, error) {
+	if q.Unit == nil {
+		return nil, fmt.Errorf("cannot marshal quantity without a unit")
+	}
+
+	return []byte(q.String()), nil
+}
+
+func (q *Quantity) UnmarshalText(text []byte) error {
+	quantity, err := ParseQuantity(string(text), DecodingRegistry())
+	if err != nil {
+		return err
+	}
+
+	*q = quantity
+
+	return nil
+}
+
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	text, err := q.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(string(text))
+}
+
+func (q *Quantity) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("quantity must be a JSON string: %w", err)
+	}
+
+	return q.UnmarshalText([]byte(text))
+}
+
+// Format implements fmt.Formatter. "%v" and "%s" print the value in its own
+// unit, "%+v" decomposes it into up to DefaultNumUnits units of its group.
+func (q Quantity) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		fmt.Fprint(f, q.Decompose(DefaultNumUnits))
+	case verb == 'v' || verb == 's':
+		fmt.Fprint(f, q.String())
+	case verb == 'q':
+		fmt.Fprintf(f, "%q", q.String())
+	default:
+		fmt.Fprintf(f, "%%!%c(units.Quantity=%s)", verb, q.String())
+	}
+}
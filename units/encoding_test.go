@@ -0,0 +1,103 @@
+package units
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestQuantityJSON(t *testing.T) {
+	var config struct {
+		Timeout Quantity `json:"timeout"`
+	}
+
+	if err := json.Unmarshal(
+		[]byte(`{"timeout": "1 hour, 30 minutes"}`),
+		&config,
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	if config.Timeout.Value != 1.5 || config.Timeout.Unit.Name != "hour" {
+		t.Fatalf("expected 1.5 hour, got %s", config.Timeout)
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := `{"timeout":"1.5 hour"}`; string(data) != exp {
+		t.Fatalf("expected %s, got %s", exp, data)
+	}
+
+	if err := json.Unmarshal(
+		[]byte(`{"timeout": 90}`),
+		&config,
+	); err == nil {
+		t.Fatal("expected error for quantity given as a number")
+	}
+
+	if _, err := json.Marshal(Quantity{}); err == nil {
+		t.Fatal("expected error for quantity without a unit")
+	}
+}
+
+func TestQuantityFormat(t *testing.T) {
+	quantity, err := ParseQuantity("1 hour, 30 minutes", EmbeddedUnitRegistry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		{"%v", "1.5 hour"},
+		{"%s", "1.5 hour"},
+		{"%+v", "1 hour, 30 minute"},
+		{"%q", `"1.5 hour"`},
+	}
+
+	for _, tc := range testCases {
+		if str := fmt.Sprintf(tc.format, quantity); str != tc.expected {
+			t.Errorf("%s: expected %q, got %q", tc.format, tc.expected, str)
+		}
+	}
+
+	if _, err := ParseQuantity("1 parsnip", EmbeddedUnitRegistry); err == nil {
+		t.Fatal("expected error for unknown alias")
+	}
+}
+
+func TestSetDecodingRegistry(t *testing.T) {
+	registry := NewUnitRegistryFilesDefault()
+
+	group, err := NewUnitGroup(strings.NewReader(
+		`[{"name": "point", "value": 1, "aliases": ["pt"]}]`,
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry.Add("typography", group)
+
+	SetDecodingRegistry(&registry)
+	t.Cleanup(func() { SetDecodingRegistry(nil) })
+
+	var quantity Quantity
+	if err := quantity.UnmarshalText([]byte("12 pt")); err != nil {
+		t.Fatal(err)
+	}
+
+	if quantity.Unit.Group().Name() != "typography" {
+		t.Fatalf("expected typography group, got %s", quantity)
+	}
+
+	SetDecodingRegistry(nil)
+
+	if indexed, _ := DefaultIndexedRegistry(); DecodingRegistry() != indexed {
+		t.Fatal("expected nil to restore the embedded registry")
+	}
+}
@@ -0,0 +1,152 @@
+package units
+
+import (
+	"iter"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// AliasMatch is a unit an alias resolves to and the group holding it.
+type AliasMatch struct {
+	Key   string // registry key of the group
+	Group *UnitGroup
+	Unit  *Unit
+}
+
+// IndexedRegistry wraps a UnitRegistry with an index of the aliases of all
+// its groups, including SI prefixes applied to prefixable units, so Find and
+// Lookup take a few map accesses. The index is built on construction and
+// rebuilt by Add; groups changed behind its back are not picked up.
+type IndexedRegistry struct {
+	registry UnitRegistry
+	index    map[string][]AliasMatch
+	folded   map[string][]AliasMatch // case-folded, unambiguous aliases
+	prefixed map[string][]AliasMatch // SI prefixes on prefixable units
+}
+
+func NewIndexedRegistry(registry UnitRegistry) *IndexedRegistry {
+	indexed := &IndexedRegistry{registry: registry}
+	indexed.rebuild()
+
+	return indexed
+}
+
+func (r *IndexedRegistry) rebuild() {
+	r.index = make(map[string][]AliasMatch, 256)
+	r.folded = make(map[string][]AliasMatch, 256)
+	r.prefixed = make(map[string][]AliasMatch, 1024)
+
+	for key, group := range r.registry.Groups() {
+		for _, alias := range group.prefixedAliases() {
+			if unit, ok := group.prefixed(alias); ok {
+				r.prefixed[alias] = append(r.prefixed[alias], AliasMatch{
+					Key:   key,
+					Group: group,
+					Unit:  unit,
+				})
+			}
+		}
+
+		for _, alias := range slices.Sorted(maps.Keys(group.aliases)) {
+			r.index[alias] = append(r.index[alias], AliasMatch{
+				Key:   key,
+				Group: group,
+				Unit:  group.aliases[alias],
+			})
+		}
+
+		if group.casePolicy == CaseSensitive {
+			continue
+		}
+
+		for _, folded := range slices.Sorted(maps.Keys(group.folded)) {
+			if unit := group.folded[folded]; unit != nil {
+				r.folded[folded] = append(r.folded[folded], AliasMatch{
+					Key:   key,
+					Group: group,
+					Unit:  unit,
+				})
+			}
+		}
+	}
+}
+
+// Lookup returns every unit alias resolves to, ordered by group key. Exact
+// matches of the normalized alias take precedence over case-folded ones,
+// which take precedence over SI prefixes applied to prefixable units. The
+// returned slice may be shared with the index and must not be modified.
+func (r *IndexedRegistry) Lookup(alias string) []AliasMatch {
+	if matches, ok := r.index[alias]; ok {
+		return matches
+	}
+
+	alias = NormalizeAlias(alias)
+
+	if matches, ok := r.index[alias]; ok {
+		return matches
+	}
+
+	if matches, ok := r.folded[foldCase(alias)]; ok {
+		return matches
+	}
+
+	return r.prefixed[alias]
+}
+
+var (
+	defaultIndexed     *IndexedRegistry
+	defaultIndexedOnce sync.Once
+)
+
+// DefaultIndexedRegistry returns the registry of DefaultRegistry wrapped in an
+// IndexedRegistry. It is built on first use and shared by all callers, so
+// groups must not be added to it.
+func DefaultIndexedRegistry() (*IndexedRegistry, error) {
+	registry, err := DefaultRegistry()
+
+	defaultIndexedOnce.Do(func() {
+		defaultIndexed = NewIndexedRegistry(registry)
+	})
+
+	return defaultIndexed, err
+}
+
+// FindUnit returns the first unit alias resolves to.
+func (r *IndexedRegistry) FindUnit(alias string) (unit *Unit, ok bool) {
+	if matches := r.Lookup(alias); len(matches) > 0 {
+		return matches[0].Unit, true
+	}
+
+	return nil, false
+}
+
+// Find returns the group of the first unit alias resolves to.
+func (r *IndexedRegistry) Find(alias string) (group *UnitGroup, ok bool) {
+	if matches := r.Lookup(alias); len(matches) > 0 {
+		return matches[0].Group, true
+	}
+
+	return nil, false
+}
+
+func (r *IndexedRegistry) Get(key string) (group *UnitGroup, ok bool) {
+	return r.registry.Get(key)
+}
+
+func (r *IndexedRegistry) Groups() iter.Seq2[string, *UnitGroup] {
+	return r.registry.Groups()
+}
+
+func (r *IndexedRegistry) Add(key string, group *UnitGroup) {
+	r.registry.Add(key, group)
+	r.rebuild()
+}
+
+func (r *IndexedRegistry) Serialize() UnitRegistryJSON {
+	return r.registry.Serialize()
+}
+
+func (r *IndexedRegistry) ToJSON() (string, error) {
+	return r.registry.ToJSON()
+}
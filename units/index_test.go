@@ -0,0 +1,161 @@
+package units
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestIndexedRegistryLookup(t *testing.T) {
+	registry := NewIndexedRegistry(EmbeddedUnitRegistry)
+
+	matches := registry.Lookup("m")
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches for 'm', got %d", len(matches))
+	}
+
+	expected := []struct{ key, unit string }{
+		{"length", "meter"},
+		{"time", "minute"},
+	}
+
+	for i, exp := range expected {
+		if matches[i].Key != exp.key || matches[i].Unit.Name != exp.unit ||
+			matches[i].Unit.Group() != matches[i].Group {
+			t.Errorf(
+				"match %d = %s/%s, want %s/%s",
+				i,
+				matches[i].Key,
+				matches[i].Unit.Name,
+				exp.key,
+				exp.unit,
+			)
+		}
+	}
+
+	if unit, ok := registry.FindUnit("hr"); !ok || unit.Name != "hour" {
+		t.Errorf("FindUnit(hr) = %v, %t", unit, ok)
+	}
+
+	if group, ok := registry.Find("km"); !ok || group.Name() != "length" {
+		t.Errorf("Find(km) = %v, %t", group, ok)
+	}
+
+	if _, ok := registry.Find("parsnip"); ok {
+		t.Error("Find(parsnip) expected no match")
+	}
+}
+
+func TestIndexedRegistryPrefixed(t *testing.T) {
+	group, err := NewUnitGroup(strings.NewReader(`{
+		"version": 2,
+		"units": [
+			{
+				"name": "gram",
+				"value": 1,
+				"symbol": "g",
+				"plural": "grams",
+				"prefixable": true
+			},
+			{"name": "pound", "value": 453.59237, "symbol": "lb"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	files := NewUnitRegistryFilesDefault()
+	files.Add("mass", group)
+
+	registry := NewIndexedRegistry(&files)
+
+	if _, ok := registry.prefixed["kg"]; !ok {
+		t.Error("expected prefixed symbols in the index")
+	}
+
+	testCases := []struct {
+		alias string
+		name  string
+	}{
+		{alias: "kg", name: "kilogram"},
+		{alias: "kilograms", name: "kilogram"},
+		{alias: "μg", name: "microgram"},
+		{alias: "dag", name: "decagram"},
+		{alias: "klb"},
+		{alias: "kgram"},
+	}
+
+	for _, tc := range testCases {
+		unit, ok := registry.FindUnit(tc.alias)
+		if ok != (len(tc.name) > 0) || ok && unit.Name != tc.name {
+			t.Errorf(
+				"FindUnit(%s) = %v, %t, want %q",
+				tc.alias,
+				unit,
+				ok,
+				tc.name,
+			)
+		}
+	}
+}
+
+func TestDefaultIndexedRegistry(t *testing.T) {
+	registry, err := DefaultIndexedRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if group, ok := registry.Find("km"); !ok || group.Name() != "length" {
+		t.Errorf("Find(km) = %v, %t", group, ok)
+	}
+
+	if again, _ := DefaultIndexedRegistry(); again != registry {
+		t.Error("expected DefaultIndexedRegistry to be shared")
+	}
+}
+
+func TestIndexedRegistryAdd(t *testing.T) {
+	files := NewUnitRegistryFilesDefault()
+	registry := NewIndexedRegistry(&files)
+
+	if _, ok := registry.Find("sp"); ok {
+		t.Fatal("expected empty registry")
+	}
+
+	loaded, err := NewUnitRegistryFiles(fstest.MapFS{
+		"units/effort.json": {
+			Data: []byte(`[{"name": "point", "value": 1, "aliases": ["sp"]}]`),
+		},
+	}, "units")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group, _ := loaded.Get("effort")
+	registry.Add("effort", group)
+
+	if unit, ok := registry.FindUnit("sp"); !ok || unit.Name != "point" {
+		t.Errorf("FindUnit(sp) after Add = %v, %t", unit, ok)
+	}
+}
+
+func BenchmarkRegistryFind(b *testing.B) {
+	registry, err := DefaultRegistry()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	indexed := NewIndexedRegistry(registry)
+
+	b.Run("scan", func(b *testing.B) {
+		for b.Loop() {
+			registry.Find("millennia")
+		}
+	})
+
+	b.Run("index", func(b *testing.B) {
+		for b.Loop() {
+			indexed.Find("millennia")
+		}
+	})
+}
@@ -0,0 +1,110 @@
+package units
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// CasePolicy selects how UnitGroup.Get treats the letter case of aliases.
+type CasePolicy int
+
+const (
+	// CaseFoldUnambiguous matches aliases in any case, except for those
+	// differing only in case between two units of the group, such as "Mm"
+	// and "mm", and SI-prefixed symbols such as "mm" or "pm", where a case
+	// change selects another prefix. Those must be written exactly.
+	CaseFoldUnambiguous CasePolicy = iota
+	// CaseSensitive matches aliases only as written.
+	CaseSensitive
+)
+
+var casePolicyNames = map[CasePolicy]string{
+	CaseFoldUnambiguous: "fold",
+	CaseSensitive:       "sensitive",
+}
+
+func (p CasePolicy) String() string {
+	if name, ok := casePolicyNames[p]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("CasePolicy(%d)", int(p))
+}
+
+// ParseCasePolicy returns the CasePolicy named "fold" or "sensitive".
+func ParseCasePolicy(name string) (CasePolicy, error) {
+	for policy, policyName := range casePolicyNames {
+		if policyName == name {
+			return policy, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown case policy '%s'", name)
+}
+
+// NormalizeAlias returns alias in Unicode NFKC form, so the micro sign and
+// the Greek mu, or fullwidth and ASCII letters, compare equal.
+func NormalizeAlias(alias string) string {
+	return norm.NFKC.String(strings.TrimSpace(alias))
+}
+
+func foldCase(alias string) string {
+	return cases.Fold().String(alias)
+}
+
+// foldAlias records alias under its case-folded form. A folded form shared by
+// two units is kept with a nil unit to mark it ambiguous.
+func (g *UnitGroup) foldAlias(alias string, unit *Unit) {
+	key := foldCase(alias)
+
+	if previous, ok := g.folded[key]; ok && previous != unit {
+		unit = nil
+	}
+
+	g.folded[key] = unit
+}
+
+func (g *UnitGroup) rebuildFolded() {
+	g.folded = make(UnitAliases, len(g.aliases))
+
+	for alias, unit := range g.aliases {
+		if !g.isPrefixedSymbol(alias) {
+			g.foldAlias(alias, unit)
+		}
+	}
+}
+
+// isPrefixedSymbol reports whether alias reads as an SI prefix symbol
+// applied to another alias of the group, as "mm" does. Folding it would let
+// "Mm" or "MM" resolve to millimeters.
+func (g *UnitGroup) isPrefixedSymbol(alias string) bool {
+	// a unit name such as "day" is a word, not deca-y
+	if unit := g.aliases[alias]; unit != nil && alias == unit.Name {
+		return false
+	}
+
+	for _, prefix := range siPrefixes {
+		rest, ok := strings.CutPrefix(alias, prefix.symbol)
+		if !ok || len(rest) == 0 {
+			continue
+		}
+
+		if _, ok := g.aliases[rest]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// SetCasePolicy changes how Get matches the case of aliases.
+func (g *UnitGroup) SetCasePolicy(policy CasePolicy) {
+	g.casePolicy = policy
+}
+
+func (g *UnitGroup) CasePolicy() CasePolicy {
+	return g.casePolicy
+}
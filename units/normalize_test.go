@@ -0,0 +1,147 @@
+package units
+
+import (
+	"strings"
+	"testing"
+)
+
+const normalizeLength = `[
+	{"name": "meter", "value": 1, "aliases": ["m"]},
+	{"name": "millimeter", "value": 0.001, "aliases": ["mm"]},
+	{"name": "micrometer", "value": 1e-6, "aliases": ["μm"]},
+	{"name": "megameter", "value": 1e6, "aliases": ["Mm"]}
+]`
+
+func TestUnitGroupGetNormalized(t *testing.T) {
+	group, err := NewUnitGroup(strings.NewReader(normalizeLength))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		alias     string
+		sensitive string // expected unit with CaseSensitive
+		fold      string // expected unit with CaseFoldUnambiguous
+	}{
+		{alias: "mm", sensitive: "millimeter", fold: "millimeter"},
+		{alias: "Mm", sensitive: "megameter", fold: "megameter"},
+		{alias: "MM", sensitive: "", fold: ""},
+		{alias: "M", sensitive: "", fold: "meter"},
+		{alias: "METER", sensitive: "", fold: "meter"},
+		{alias: "µm", sensitive: "micrometer", fold: "micrometer"},
+		{alias: "μm", sensitive: "micrometer", fold: "micrometer"},
+		{alias: "ｍｍ", sensitive: "millimeter", fold: "millimeter"},
+		{alias: "ΜM", sensitive: "", fold: ""},
+		{alias: "MILLIMETER", sensitive: "", fold: "millimeter"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			for policy, expected := range map[CasePolicy]string{
+				CaseSensitive:       tc.sensitive,
+				CaseFoldUnambiguous: tc.fold,
+			} {
+				group.SetCasePolicy(policy)
+
+				unit, ok := group.Get(tc.alias)
+
+				name := ""
+				if ok {
+					name = unit.Name
+				}
+
+				if name != expected {
+					t.Errorf(
+						"%s: Get() = '%s', want '%s'",
+						policy,
+						name,
+						expected,
+					)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbeddedPrefixedSymbolsCase(t *testing.T) {
+	testCases := []struct {
+		group    string
+		alias    string
+		expected string
+	}{
+		{group: "length", alias: "Mm", expected: ""},
+		{group: "length", alias: "MM", expected: ""},
+		{group: "length", alias: "PM", expected: ""},
+		{group: "length", alias: "NM", expected: ""},
+		{group: "length", alias: "Nm", expected: ""},
+		{group: "length", alias: "KM", expected: ""},
+		{group: "length", alias: "pm", expected: "picometer"},
+		{group: "length", alias: "Kilometers", expected: "kilometer"},
+		{group: "length", alias: "FT", expected: "foot"},
+		{group: "time", alias: "DAY", expected: "day"},
+		{group: "time", alias: "Hours", expected: "hour"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			group, _ := EmbeddedUnitRegistry.Get(tc.group)
+
+			unit, ok := group.Get(tc.alias)
+
+			name := ""
+			if ok {
+				name = unit.Name
+			}
+
+			if name != tc.expected {
+				t.Errorf("Get() = '%s', want '%s'", name, tc.expected)
+			}
+		})
+	}
+}
+
+func TestParseTermNormalized(t *testing.T) {
+	term, err := ParseTerm("５　ｋｍ")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if term.Value != 5 || term.Alias != "km" {
+		t.Errorf("ParseTerm() = %v %s, want 5 km", term.Value, term.Alias)
+	}
+
+	quantity, err := ParseQuantity("5 HOURS, 30 Minutes", EmbeddedUnitRegistry)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quantity.Base() != 19800 {
+		t.Errorf("Base() = %v, want 19800", quantity.Base())
+	}
+}
+
+func TestIndexedRegistryFolded(t *testing.T) {
+	registry := NewIndexedRegistry(EmbeddedUnitRegistry)
+
+	if unit, ok := registry.FindUnit("Hours"); !ok || unit.Name != "hour" {
+		t.Errorf("FindUnit(Hours) = %v, %t", unit, ok)
+	}
+
+	if unit, ok := registry.FindUnit("µm"); !ok ||
+		unit.Name != "micrometer" {
+		t.Errorf("FindUnit(µm) = %v, %t", unit, ok)
+	}
+}
+
+func TestParseCasePolicy(t *testing.T) {
+	for policy := range casePolicyNames {
+		parsed, err := ParseCasePolicy(policy.String())
+		if err != nil || parsed != policy {
+			t.Errorf("ParseCasePolicy(%s) = %v, %v", policy, parsed, err)
+		}
+	}
+
+	if _, err := ParseCasePolicy("upper"); err == nil {
+		t.Error("expected error for unknown policy")
+	}
+}
@@ -0,0 +1,317 @@
+package units
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoGroup           = errors.New("unit does not belong to a unit group")
+	ErrIncompatibleUnits = errors.New("units belong to different unit groups")
+	ErrDivisionByZero    = errors.New("division by zero")
+	ErrOffsetUnit        = errors.New("unit with an offset cannot be summed")
+)
+
+// Term is a single "<value> <alias>" part of a measure such as
+// "1 hour, 15 minutes". Text keeps the value as written.
+type Term struct {
+	Value float64
+	Alias string
+	Text  string
+}
+
+// ParseTerm parses a single "<value> <alias>" term. The term is normalized
+// to Unicode NFKC first, so fullwidth digits, letters and spaces are read as
+// their ASCII forms.
+func ParseTerm(raw string) (Term, error) {
+	value, alias, found := strings.Cut(NormalizeAlias(raw), " ")
+
+	if !found {
+		return Term{}, fmt.Errorf("raw measure '%s' is malformed", raw)
+	}
+
+	if len(value) == 0 {
+		return Term{}, fmt.Errorf("raw measure '%s' missing value", raw)
+	}
+
+	if len(alias) == 0 {
+		return Term{}, fmt.Errorf("raw measure '%s' missing unit alias", raw)
+	}
+
+	numValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Term{}, fmt.Errorf(
+			"raw measure '%s' value failed to be parsed: %w",
+			raw,
+			err,
+		)
+	}
+
+	return Term{Value: numValue, Alias: alias, Text: value}, nil
+}
+
+// ParseTerms parses comma-separated terms.
+func ParseTerms(measures string) ([]Term, error) {
+	rawTerms := strings.Split(measures, ",")
+	terms := make([]Term, 0, len(rawTerms))
+
+	for _, raw := range rawTerms {
+		term, err := ParseTerm(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		terms = append(terms, term)
+	}
+
+	return terms, nil
+}
+
+// Decompose formats value, given in base units, as whole counts of units
+// taken from largest to smallest. At most numUnits units are printed, the
+// last of them with two decimals. Whatever remains below the smallest unit is
+// dropped. Negative values are decomposed by magnitude and prefixed with a
+// minus sign. Units must be sorted largest first.
+func (s UnitsSlice) Decompose(value float64, numUnits int) string {
+	return s.decompose(value, numUnits, false)
+}
+
+// DecomposeRemainder is Decompose keeping the remainder that no smaller unit
+// can take as a fraction of the smallest unit.
+func (s UnitsSlice) DecomposeRemainder(value float64, numUnits int) string {
+	return s.decompose(value, numUnits, true)
+}
+
+func (s UnitsSlice) decompose(
+	value float64,
+	numUnits int,
+	remainder bool,
+) string {
+	if value < 0 {
+		return "-" + s.decompose(-value, numUnits, remainder)
+	}
+
+	result := make([]string, 0, numUnits)
+
+	used_units := 0
+	leftover := value
+
+	for i, unit := range s {
+		if leftover == 0.0 {
+			break
+		}
+
+		div := leftover / unit.Multiplier
+
+		part := math.Floor(div)
+		last := remainder && i == len(s)-1
+
+		if part <= 0.0 && !last {
+			continue
+		}
+
+		used_units++
+
+		if used_units == numUnits || (last && div != part) {
+			result = append(result, fmt.Sprintf("%.02f %s", div, unit.Name))
+			break
+		}
+
+		leftover = leftover - (part * unit.Multiplier)
+
+		result = append(result, fmt.Sprintf("%d %s", int(part), unit.Name))
+	}
+
+	if len(result) == 0 && len(s) > 0 {
+		return fmt.Sprintf("0 %s", s[len(s)-1].Name)
+	}
+
+	return strings.Join(result, ", ")
+}
+
+// UnitsUpTo lists units of the group not larger than max, largest first.
+// Units with an offset are left out, as values cannot be split across them.
+func (g *UnitGroup) UnitsUpTo(max float64) UnitsSlice {
+	slice := make(UnitsSlice, 0, g.Length())
+
+	for u := range g.IterBackward() {
+		if u.Multiplier <= max && !u.HasOffset() {
+			slice = append(slice, u)
+		}
+	}
+
+	return slice
+}
+
+// Quantity is a value expressed in a unit of a UnitGroup. Arithmetic between
+// quantities requires both units to come from the same group. The zero value
+// has no unit: Base and String treat it as the number 0, while Convert, Add,
+// Sub, Ratio and Compare return ErrNoGroup.
+type Quantity struct {
+	Value float64
+	Unit  *Unit
+}
+
+func NewQuantity(value float64, unit *Unit) Quantity {
+	return Quantity{Value: value, Unit: unit}
+}
+
+// ParseQuantity parses a measure such as "1 hour, 15 minutes" within group.
+// The result is expressed in the unit of the first term.
+func (g *UnitGroup) ParseQuantity(measures string) (Quantity, error) {
+	terms, err := ParseTerms(measures)
+	if err != nil {
+		return Quantity{}, err
+	}
+
+	var unit *Unit
+	base := 0.0
+
+	for _, term := range terms {
+		termUnit, ok := g.Get(term.Alias)
+		if !ok {
+			return Quantity{}, g.UnknownAlias(term.Alias)
+		}
+
+		if termUnit.HasOffset() && len(terms) > 1 {
+			return Quantity{}, fmt.Errorf(
+				"%w: '%s' in '%s'",
+				ErrOffsetUnit,
+				termUnit.Name,
+				measures,
+			)
+		}
+
+		if unit == nil {
+			unit = termUnit
+		}
+
+		base += term.Value * termUnit.Multiplier
+	}
+
+	return Quantity{Value: base / unit.Multiplier, Unit: unit}, nil
+}
+
+// Base returns the quantity in base units of its group, including the offset
+// of units such as degrees Fahrenheit.
+func (q Quantity) Base() float64 {
+	if q.Unit == nil {
+		return q.Value
+	}
+
+	return q.Unit.toBase(q.Value)
+}
+
+func (q Quantity) checkCompatible(other Quantity) error {
+	if q.Unit == nil || other.Unit == nil ||
+		q.Unit.group == nil || other.Unit.group == nil {
+		return ErrNoGroup
+	}
+
+	if q.Unit.group != other.Unit.group {
+		return fmt.Errorf(
+			"%w: '%s' and '%s'",
+			ErrIncompatibleUnits,
+			q.Unit.Name,
+			other.Unit.Name,
+		)
+	}
+
+	return nil
+}
+
+// Convert expresses the quantity in another unit of the same group.
+func (q Quantity) Convert(to *Unit) (Quantity, error) {
+	target := Quantity{Unit: to}
+
+	if err := q.checkCompatible(target); err != nil {
+		return Quantity{}, err
+	}
+
+	target.Value = to.fromBase(q.Base())
+
+	return target, nil
+}
+
+// Add returns q + other in the unit of q. Quantities in units with an offset
+// cannot be added and return ErrOffsetUnit.
+func (q Quantity) Add(other Quantity) (Quantity, error) {
+	converted, err := other.Convert(q.Unit)
+	if err != nil {
+		return Quantity{}, err
+	}
+
+	for _, unit := range []*Unit{q.Unit, other.Unit} {
+		if unit.HasOffset() {
+			return Quantity{}, fmt.Errorf("%w: '%s'", ErrOffsetUnit, unit.Name)
+		}
+	}
+
+	return Quantity{Value: q.Value + converted.Value, Unit: q.Unit}, nil
+}
+
+// Sub returns q - other in the unit of q.
+func (q Quantity) Sub(other Quantity) (Quantity, error) {
+	return q.Add(other.Mul(-1))
+}
+
+// Mul scales the quantity by factor.
+func (q Quantity) Mul(factor float64) Quantity {
+	return Quantity{Value: q.Value * factor, Unit: q.Unit}
+}
+
+// Div divides the quantity by divisor.
+func (q Quantity) Div(divisor float64) (Quantity, error) {
+	if divisor == 0 {
+		return Quantity{}, ErrDivisionByZero
+	}
+
+	return Quantity{Value: q.Value / divisor, Unit: q.Unit}, nil
+}
+
+// Ratio returns q / other as a plain number.
+func (q Quantity) Ratio(other Quantity) (float64, error) {
+	if err := q.checkCompatible(other); err != nil {
+		return 0, err
+	}
+
+	if other.Value == 0 {
+		return 0, ErrDivisionByZero
+	}
+
+	return q.Base() / other.Base(), nil
+}
+
+// Compare returns -1, 0 or +1 depending on whether q is less than, equal to
+// or greater than other.
+func (q Quantity) Compare(other Quantity) (int, error) {
+	if err := q.checkCompatible(other); err != nil {
+		return 0, err
+	}
+
+	return cmp.Compare(q.Base(), other.Base()), nil
+}
+
+// Decompose formats the quantity in at most numUnits units of its group.
+func (q Quantity) Decompose(numUnits int) string {
+	if q.Unit == nil || q.Unit.group == nil {
+		return q.String()
+	}
+
+	return q.Unit.group.UnitsUpTo(math.Abs(q.Base())).Decompose(
+		q.Base(),
+		numUnits,
+	)
+}
+
+func (q Quantity) String() string {
+	if q.Unit == nil {
+		return fmt.Sprintf("%g", q.Value)
+	}
+
+	return fmt.Sprintf("%g %s", q.Value, q.Unit.Name)
+}
@@ -0,0 +1,223 @@
+package units
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func helperTimeGroup(t *testing.T) *UnitGroup {
+	t.Helper()
+
+	group, ok := EmbeddedUnitRegistry.Get("time")
+	if !ok {
+		t.Fatal("expected embedded 'time' group")
+	}
+
+	return group
+}
+
+func TestParseTerms(t *testing.T) {
+	terms, err := ParseTerms("1 hour, 15.5 min")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Term{
+		{Value: 1, Alias: "hour", Text: "1"},
+		{Value: 15.5, Alias: "min", Text: "15.5"},
+	}
+
+	if len(terms) != len(expected) {
+		t.Fatalf("expected %d terms, got %d", len(expected), len(terms))
+	}
+
+	for i := range expected {
+		if terms[i] != expected[i] {
+			t.Fatalf("expected term %+v, got %+v", expected[i], terms[i])
+		}
+	}
+
+	testCases := []struct {
+		input   string
+		wantErr string
+	}{
+		{"1hour", "raw measure '1hour' is malformed"},
+		{" hour", "raw measure ' hour' is malformed"},
+		{"1 ", "raw measure '1 ' is malformed"},
+		{
+			"x hour",
+			"raw measure 'x hour' value failed to be parsed: " +
+				"strconv.ParseFloat: parsing \"x\": invalid syntax",
+		},
+	}
+
+	for _, tc := range testCases {
+		if _, err := ParseTerms(tc.input); err == nil ||
+			err.Error() != tc.wantErr {
+			t.Errorf("expected error %q, got %v", tc.wantErr, err)
+		}
+	}
+}
+
+func TestUnitGroupParseQuantity(t *testing.T) {
+	group := helperTimeGroup(t)
+
+	quantity, err := group.ParseQuantity("1 hour, 30 minutes")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quantity.Value != 1.5 || quantity.Unit.Name != "hour" {
+		t.Fatalf("expected 1.5 hour, got %s", quantity)
+	}
+
+	if quantity.Unit.Group() != group {
+		t.Fatal("expected unit to point to its group")
+	}
+
+	if _, err := group.ParseQuantity("1 meter"); err == nil {
+		t.Fatal("expected error for alias outside of the group")
+	}
+}
+
+func TestQuantityArithmetic(t *testing.T) {
+	group := helperTimeGroup(t)
+	hour, _ := group.Get("hour")
+	minute, _ := group.Get("minute")
+
+	a := NewQuantity(2, hour)
+	b := NewQuantity(30, minute)
+
+	sum, err := a.Add(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sum.String() != "2.5 hour" {
+		t.Fatalf("expected '2.5 hour', got '%s'", sum)
+	}
+
+	diff, err := b.Sub(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if diff.String() != "-90 minute" {
+		t.Fatalf("expected '-90 minute', got '%s'", diff)
+	}
+
+	if product := b.Mul(4); product.String() != "120 minute" {
+		t.Fatalf("expected '120 minute', got '%s'", product)
+	}
+
+	quotient, err := a.Div(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if quotient.String() != "0.5 hour" {
+		t.Fatalf("expected '0.5 hour', got '%s'", quotient)
+	}
+
+	if ratio, err := a.Ratio(b); err != nil || ratio != 4 {
+		t.Fatalf("expected ratio 4, got %f (%v)", ratio, err)
+	}
+
+	converted, err := a.Convert(minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if converted.String() != "120 minute" {
+		t.Fatalf("expected '120 minute', got '%s'", converted)
+	}
+
+	if order, err := b.Compare(a); err != nil || order != -1 {
+		t.Fatalf("expected -1, got %d (%v)", order, err)
+	}
+
+	if order, err := converted.Compare(a); err != nil || order != 0 {
+		t.Fatalf("expected 0, got %d (%v)", order, err)
+	}
+
+	if formatted := sum.Decompose(3); formatted != "2 hour, 30 minute" {
+		t.Fatalf("expected '2 hour, 30 minute', got '%s'", formatted)
+	}
+
+	if formatted := diff.Decompose(3); formatted != "-1 hour, 30 minute" {
+		t.Fatalf("expected '-1 hour, 30 minute', got '%s'", formatted)
+	}
+}
+
+func TestQuantityZeroValue(t *testing.T) {
+	var zero Quantity
+
+	if str := zero.String(); str != "0" {
+		t.Errorf("expected '0', got '%s'", str)
+	}
+
+	if base := zero.Base(); base != 0 {
+		t.Errorf("expected base 0, got %g", base)
+	}
+
+	if str := zero.Decompose(2); str != "0" {
+		t.Errorf("expected '0', got '%s'", str)
+	}
+
+	if str := fmt.Sprintf("%v %+v", zero, zero); str != "0 0" {
+		t.Errorf("expected '0 0', got '%s'", str)
+	}
+
+	hour, _ := helperTimeGroup(t).Get("hour")
+
+	if _, err := zero.Add(NewQuantity(1, hour)); !errors.Is(err, ErrNoGroup) {
+		t.Errorf("expected ErrNoGroup, got %v", err)
+	}
+
+	if _, err := NewQuantity(1, hour).Compare(zero); !errors.Is(
+		err,
+		ErrNoGroup,
+	) {
+		t.Errorf("expected ErrNoGroup, got %v", err)
+	}
+
+	if _, err := zero.MarshalText(); err == nil {
+		t.Error("expected error marshaling a quantity without a unit")
+	}
+}
+
+func TestQuantityIncompatible(t *testing.T) {
+	group := helperTimeGroup(t)
+	hour, _ := group.Get("hour")
+
+	length, _ := EmbeddedUnitRegistry.Get("length")
+	meter, _ := length.Get("meter")
+
+	a := NewQuantity(1, hour)
+	b := NewQuantity(1, meter)
+
+	if _, err := a.Add(b); !errors.Is(err, ErrIncompatibleUnits) {
+		t.Fatalf("expected ErrIncompatibleUnits, got %v", err)
+	}
+
+	if _, err := a.Compare(b); !errors.Is(err, ErrIncompatibleUnits) {
+		t.Fatalf("expected ErrIncompatibleUnits, got %v", err)
+	}
+
+	orphan := NewQuantity(1, &Unit{Name: "orphan", Multiplier: 1})
+	if _, err := a.Convert(orphan.Unit); !errors.Is(err, ErrNoGroup) {
+		t.Fatalf("expected ErrNoGroup, got %v", err)
+	}
+
+	if _, err := a.Div(0); !errors.Is(err, ErrDivisionByZero) {
+		t.Fatalf("expected ErrDivisionByZero, got %v", err)
+	}
+
+	if _, err := a.Ratio(NewQuantity(0, hour)); !errors.Is(
+		err,
+		ErrDivisionByZero,
+	) {
+		t.Fatalf("expected ErrDivisionByZero, got %v", err)
+	}
+}
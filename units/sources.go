@@ -0,0 +1,256 @@
+package units
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// UnitsPathEnv lists extra unit directories separated by the OS path list
+// separator. They are loaded after the user config directory.
+const UnitsPathEnv = "REFSCALER_UNITS_PATH"
+
+// UnitSource is a directory of unit files merged into a registry. Groups it
+// defines extend groups of the same name already loaded, or replace them
+// when Replace is set.
+type UnitSource struct {
+	Path    string
+	Replace bool
+}
+
+// UserUnitSources returns $XDG_CONFIG_HOME/refscaler/units, when it exists,
+// followed by the directories listed in UnitsPathEnv.
+func UserUnitSources() []UnitSource {
+	var sources []UnitSource
+
+	if config, err := os.UserConfigDir(); err == nil {
+		path := filepath.Join(config, "refscaler", "units")
+
+		if info, err := os.Stat(path); err == nil && info.IsDir() {
+			sources = append(sources, UnitSource{Path: path})
+		}
+	}
+
+	for _, path := range filepath.SplitList(os.Getenv(UnitsPathEnv)) {
+		if len(path) > 0 {
+			sources = append(sources, UnitSource{Path: path})
+		}
+	}
+
+	return sources
+}
+
+// MergeConflict describes a definition overridden while merging a source.
+type MergeConflict struct {
+	Source  string
+	Group   string
+	Message string
+}
+
+func (c MergeConflict) String() string {
+	return fmt.Sprintf("%s: %s: %s", c.Source, c.Group, c.Message)
+}
+
+func (g *UnitGroup) clone() *UnitGroup {
+	cloned := newUnitGroupDefault()
+	cloned.name = g.name
+	cloned.dimension = g.dimension
+	cloned.description = g.description
+	cloned.casePolicy = g.casePolicy
+	mapped := make(map[*Unit]*Unit, len(g.units))
+
+	for _, unit := range g.units {
+		copied := *unit
+		copied.group = cloned
+		mapped[unit] = &copied
+		cloned.units = append(cloned.units, &copied)
+	}
+
+	for alias, unit := range g.aliases {
+		cloned.aliases[alias] = mapped[unit]
+	}
+
+	if g.base != nil {
+		cloned.base = mapped[g.base]
+	}
+
+	cloned.rebuildFolded()
+
+	for _, shadowed := range g.shadowed {
+		cloned.shadowed = append(cloned.shadowed, shadowedAlias{
+			alias:    shadowed.alias,
+			previous: mapped[shadowed.previous],
+			unit:     mapped[shadowed.unit],
+		})
+	}
+
+	return cloned
+}
+
+func (g *UnitGroup) unitByName(name string) (*Unit, bool) {
+	for _, unit := range g.units {
+		if unit.Name == name {
+			return unit, true
+		}
+	}
+
+	return nil, false
+}
+
+// extend adds the units and aliases of other to the group. A unit with the
+// same name is redefined and an alias of another unit is moved, both are
+// returned as conflicts.
+func (g *UnitGroup) extend(other *UnitGroup) (conflicts []string) {
+	mapped := make(map[*Unit]*Unit, len(other.units))
+
+	for _, unit := range other.units {
+		existing, ok := g.unitByName(unit.Name)
+
+		if !ok {
+			existing = &Unit{Name: unit.Name, group: g}
+			g.units = append(g.units, existing)
+		} else if !sameMultiplier(existing.Multiplier, unit.Multiplier) {
+			conflicts = append(conflicts, fmt.Sprintf(
+				"unit '%s' redefined from %g to %g",
+				unit.Name,
+				existing.Multiplier,
+				unit.Multiplier,
+			))
+		}
+
+		existing.Multiplier = unit.Multiplier
+		existing.exact = unit.exact
+		existing.Systems = slices.Clone(unit.Systems)
+		existing.Symbol = unit.Symbol
+		existing.Plural = unit.Plural
+		existing.Description = unit.Description
+		existing.Prefixable = unit.Prefixable
+		existing.Offset = unit.Offset
+		mapped[unit] = existing
+	}
+
+	for _, alias := range slices.Sorted(maps.Keys(other.aliases)) {
+		unit := mapped[other.aliases[alias]]
+
+		if previous, ok := g.aliases[alias]; ok && previous != unit {
+			conflicts = append(conflicts, fmt.Sprintf(
+				"alias '%s' moved from unit '%s' to '%s'",
+				alias,
+				previous.Name,
+				unit.Name,
+			))
+		}
+
+		g.aliases[alias] = unit
+	}
+
+	g.rebuildFolded()
+	g.sortUnits()
+
+	return conflicts
+}
+
+// Merge adds the groups of other, loaded from source, to the registry. New
+// groups are added as they are. Existing groups are extended, or replaced
+// when replace is set. Units and aliases redefined while extending, groups
+// replaced and aliases added that another group already defines are
+// reported as conflicts; the definitions of other win.
+func (r *UnitRegistryFiles) Merge(
+	source string,
+	other UnitRegistryFiles,
+	replace bool,
+) (conflicts []MergeConflict) {
+	for key, group := range other.Groups() {
+		var messages []string
+
+		existing, ok := r.Get(key)
+		previous := make(map[string]*Unit)
+
+		switch {
+		case !ok:
+			r.Add(key, group)
+		case replace:
+			maps.Copy(previous, existing.aliases)
+			messages = append(messages, fmt.Sprintf(
+				"group replaced, %d units dropped",
+				existing.Length(),
+			))
+			r.Add(key, group)
+		default:
+			maps.Copy(previous, existing.aliases)
+			messages = existing.extend(group)
+		}
+
+		for _, alias := range slices.Sorted(maps.Keys(group.aliases)) {
+			if _, ok := previous[alias]; !ok {
+				messages = append(messages, r.aliasCollisions(key, alias)...)
+			}
+		}
+
+		for _, message := range messages {
+			conflicts = append(conflicts, MergeConflict{
+				Source:  source,
+				Group:   key,
+				Message: message,
+			})
+		}
+	}
+
+	return conflicts
+}
+
+// aliasCollisions reports the groups other than key that define alias, so
+// Find resolves it by group order rather than by the unit just added.
+func (r *UnitRegistryFiles) aliasCollisions(
+	key, alias string,
+) (messages []string) {
+	for other, group := range r.Groups() {
+		if other == key {
+			continue
+		}
+
+		if _, ok := group.aliases[alias]; ok {
+			messages = append(messages, fmt.Sprintf(
+				"alias '%s' is also defined by group '%s'",
+				alias,
+				other,
+			))
+		}
+	}
+
+	return messages
+}
+
+// LoadRegistry returns a copy of base merged with the unit directories of
+// sources in order, so later sources override earlier ones. base itself is
+// never modified.
+func LoadRegistry(
+	base UnitRegistry,
+	sources ...UnitSource,
+) (registry UnitRegistryFiles, conflicts []MergeConflict, err error) {
+	registry = NewUnitRegistryFilesDefault()
+
+	for key, group := range base.Groups() {
+		registry.Add(key, group.clone())
+	}
+
+	for _, source := range sources {
+		loaded, err := NewUnitRegistryFiles(os.DirFS(source.Path), ".")
+		if err != nil {
+			return registry, conflicts, fmt.Errorf(
+				"failed to load units from '%s': %w",
+				source.Path,
+				err,
+			)
+		}
+
+		conflicts = append(
+			conflicts,
+			registry.Merge(source.Path, loaded, source.Replace)...,
+		)
+	}
+
+	return registry, conflicts, nil
+}
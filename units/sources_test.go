@@ -0,0 +1,185 @@
+package units
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func helperWriteUnits(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadRegistryExtend(t *testing.T) {
+	dir := t.TempDir()
+	helperWriteUnits(t, dir, "time.json", `[
+		{"name": "sprint", "value": 1209600, "aliases": ["sprints"]},
+		{"name": "month", "value": 2628000, "aliases": ["mo"]},
+		{"name": "moment", "value": 90, "aliases": ["m"]}
+	]`)
+
+	registry, conflicts, err := LoadRegistry(
+		EmbeddedUnitRegistry,
+		UnitSource{Path: dir},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group, _ := registry.Get("time")
+
+	if unit, ok := group.Get("sprints"); !ok || unit.Multiplier != 1209600 {
+		t.Errorf("expected 'sprints' to resolve to the new unit")
+	}
+
+	if unit, _ := group.Get("hour"); unit.Group() != group {
+		t.Errorf("existing units must belong to the merged group")
+	}
+
+	expected := []string{
+		dir + ": time: unit 'month' redefined from 2.592e+06 to 2.628e+06",
+		dir + ": time: alias 'm' moved from unit 'minute' to 'moment'",
+	}
+
+	if len(conflicts) != len(expected) {
+		t.Fatalf("expected %d conflicts, got %v", len(expected), conflicts)
+	}
+
+	for i, exp := range expected {
+		if conflicts[i].String() != exp {
+			t.Errorf("conflict %d = %q, want %q", i, conflicts[i], exp)
+		}
+	}
+
+	embedded, _ := EmbeddedUnitRegistry.Get("time")
+	if unit, _ := embedded.Get("m"); unit.Name != "minute" {
+		t.Errorf("embedded registry was modified, 'm' is %s", unit.Name)
+	}
+
+	if _, ok := embedded.Get("sprint"); ok {
+		t.Error("embedded registry was modified, 'sprint' was added")
+	}
+}
+
+func TestLoadRegistryReplace(t *testing.T) {
+	dir := t.TempDir()
+	helperWriteUnits(t, dir, "time.json", `[
+		{"name": "tick", "value": 1, "aliases": ["ticks"]}
+	]`)
+
+	registry, conflicts, err := LoadRegistry(
+		EmbeddedUnitRegistry,
+		UnitSource{Path: dir, Replace: true},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embedded, _ := EmbeddedUnitRegistry.Get("time")
+	expected := fmt.Sprintf(
+		"%s: time: group replaced, %d units dropped",
+		dir,
+		embedded.Length(),
+	)
+
+	if len(conflicts) != 1 || conflicts[0].String() != expected {
+		t.Errorf("expected conflict %q, got %v", expected, conflicts)
+	}
+
+	group, _ := registry.Get("time")
+
+	if group.Length() != 1 {
+		t.Errorf("expected replaced group with 1 unit, got %d", group.Length())
+	}
+
+	if _, ok := group.Get("hour"); ok {
+		t.Error("expected 'hour' to be gone from the replaced group")
+	}
+
+	if _, ok := registry.Get("length"); !ok {
+		t.Error("expected other groups to stay")
+	}
+}
+
+func TestLoadRegistryAliasCollisions(t *testing.T) {
+	dir := t.TempDir()
+	helperWriteUnits(t, dir, "effort.json", `[
+		{"name": "point", "value": 1, "aliases": ["pts", "hour"]}
+	]`)
+	helperWriteUnits(t, dir, "length.json", `[
+		{"name": "hand", "value": 0.1016, "aliases": ["hh", "min"]}
+	]`)
+
+	_, conflicts, err := LoadRegistry(
+		EmbeddedUnitRegistry,
+		UnitSource{Path: dir},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		dir + ": effort: alias 'hour' is also defined by group 'time'",
+		dir + ": length: alias 'min' is also defined by group 'time'",
+	}
+
+	if len(conflicts) != len(expected) {
+		t.Fatalf("expected %d conflicts, got %v", len(expected), conflicts)
+	}
+
+	for i, exp := range expected {
+		if conflicts[i].String() != exp {
+			t.Errorf("conflict %d = %q, want %q", i, conflicts[i], exp)
+		}
+	}
+}
+
+func TestLoadRegistryMissingDir(t *testing.T) {
+	_, _, err := LoadRegistry(
+		EmbeddedUnitRegistry,
+		UnitSource{Path: filepath.Join(t.TempDir(), "missing")},
+	)
+	if err == nil {
+		t.Error("expected error for missing directory")
+	}
+}
+
+func TestUserUnitSources(t *testing.T) {
+	config := t.TempDir()
+	user := filepath.Join(config, "refscaler", "units")
+	helperWriteUnits(t, user, "effort.json", `[]`)
+
+	t.Setenv("XDG_CONFIG_HOME", config)
+	t.Setenv(
+		UnitsPathEnv,
+		"/opt/units"+string(os.PathListSeparator)+"/srv/units",
+	)
+
+	expected := []UnitSource{
+		{Path: user},
+		{Path: "/opt/units"},
+		{Path: "/srv/units"},
+	}
+
+	sources := UserUnitSources()
+
+	if len(sources) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, sources)
+	}
+
+	for i, exp := range expected {
+		if sources[i] != exp {
+			t.Errorf("source %d = %v, want %v", i, sources[i], exp)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package units
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// SQLFormat selects how values are stored in a database column. The zero
+// value stores floats in base units, as refscaler.MeasureValue does.
+type SQLFormat int
+
+const (
+	// SQLFormatBase stores a float in the base unit of the group.
+	SQLFormatBase SQLFormat = iota
+	// SQLFormatText stores the canonical text form such as "1.5 hour".
+	SQLFormatText
+)
+
+// SQLQuantity adapts a Quantity to sql.Scanner and driver.Valuer. Quantity
+// cannot implement driver.Valuer itself, because its Value field would clash
+// with the Value method.
+//
+// Scan accepts both formats regardless of Format. Numbers are read in base
+// units and converted to the unit already set on Quantity, so it must be
+// set before scanning a SQLFormatBase column.
+type SQLQuantity struct {
+	Quantity Quantity
+	Format   SQLFormat
+}
+
+func (s SQLQuantity) Value() (driver.Value, error) {
+	if s.Quantity.Unit == nil {
+		return nil, fmt.Errorf("cannot store quantity without a unit")
+	}
+
+	switch s.Format {
+	case SQLFormatText:
+		return s.Quantity.String(), nil
+	case SQLFormatBase:
+		return s.Quantity.Base(), nil
+	default:
+		return nil, fmt.Errorf("unknown sql format %d", s.Format)
+	}
+}
+
+func (s *SQLQuantity) Scan(src any) error {
+	switch v := src.(type) {
+	case string:
+		return s.Quantity.UnmarshalText([]byte(v))
+	case []byte:
+		return s.Quantity.UnmarshalText(v)
+	case float64:
+		return s.scanBase(v)
+	case int64:
+		return s.scanBase(float64(v))
+	case nil:
+		return fmt.Errorf("cannot scan NULL into quantity")
+	default:
+		return fmt.Errorf("cannot scan %T into quantity", src)
+	}
+}
+
+func (s *SQLQuantity) scanBase(value float64) error {
+	if s.Quantity.Unit == nil {
+		return fmt.Errorf(
+			"cannot scan number %g into quantity without a unit",
+			value,
+		)
+	}
+
+	s.Quantity.Value = s.Quantity.Unit.fromBase(value)
+
+	return nil
+}
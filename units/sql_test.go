@@ -0,0 +1,86 @@
+package units
+
+import (
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+)
+
+func TestSQLQuantityRoundTrip(t *testing.T) {
+	group := helperTimeGroup(t)
+	hour, _ := group.Get("hour")
+
+	testCases := []struct {
+		name   string
+		format SQLFormat
+		stored any
+	}{
+		{name: "text", format: SQLFormatText, stored: "1.5 hour"},
+		{name: "base", format: SQLFormatBase, stored: 5400.0},
+		{name: "default", stored: 5400.0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, err := internal.OpenFakeSQL(t.Name())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			stored := SQLQuantity{
+				Quantity: NewQuantity(1.5, hour),
+				Format:   tc.format,
+			}
+
+			_, err = db.Exec("INSERT INTO quantities VALUES (?)", stored)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var raw any
+			if err := db.QueryRow("SELECT value").Scan(&raw); err != nil {
+				t.Fatal(err)
+			}
+
+			if raw != tc.stored {
+				t.Errorf("stored %#v, want %#v", raw, tc.stored)
+			}
+
+			scanned := SQLQuantity{Quantity: NewQuantity(0, hour)}
+			if err := db.QueryRow("SELECT value").Scan(&scanned); err != nil {
+				t.Fatal(err)
+			}
+
+			if scanned.Quantity.Value != 1.5 || scanned.Quantity.Unit != hour {
+				t.Errorf("scanned %v, want 1.5 hour", scanned.Quantity)
+			}
+		})
+	}
+}
+
+func TestSQLQuantityErrors(t *testing.T) {
+	if _, err := (SQLQuantity{}).Value(); err == nil {
+		t.Error("Value() without a unit expected error")
+	}
+
+	var scanned SQLQuantity
+
+	testCases := []struct {
+		name string
+		src  any
+	}{
+		{name: "number without unit", src: 60.0},
+		{name: "null", src: nil},
+		{name: "unknown alias", src: "3 parsnips"},
+		{name: "unsupported type", src: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := scanned.Scan(tc.src); err == nil {
+				t.Errorf("Scan(%#v) expected error", tc.src)
+			}
+		})
+	}
+}
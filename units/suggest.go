@@ -0,0 +1,182 @@
+package units
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// MaxSuggestions is the number of aliases UnknownAliasError suggests.
+const MaxSuggestions = 3
+
+// UnknownAliasError reports an alias that does not resolve to a unit. Group
+// is the group searched, or nil when the whole registry was searched.
+type UnknownAliasError struct {
+	Alias       string
+	Group       *UnitGroup
+	Suggestions []string     // close aliases, closest first
+	Elsewhere   []AliasMatch // units of other groups named by Alias
+}
+
+func (e *UnknownAliasError) Error() string {
+	if e.Group == nil {
+		return fmt.Sprintf(
+			"failed to determine unit group for alias '%s'",
+			e.Alias,
+		)
+	}
+
+	return fmt.Sprintf("alias '%s' not found", e.Alias)
+}
+
+// Hint explains the error to a user: which groups define the alias when the
+// dimension is wrong, or which aliases are close to it. It is empty when
+// there is nothing to suggest.
+func (e *UnknownAliasError) Hint() string {
+	if len(e.Elsewhere) > 0 {
+		keys := make([]string, 0, len(e.Elsewhere))
+		for _, match := range e.Elsewhere {
+			keys = append(keys, match.Key)
+		}
+
+		return fmt.Sprintf(
+			"%q is a unit of %s, not %s",
+			e.Alias,
+			strings.Join(keys, ", "),
+			e.Group.Name(),
+		)
+	}
+
+	if len(e.Suggestions) == 0 {
+		return ""
+	}
+
+	quoted := make([]string, 0, len(e.Suggestions))
+	for _, suggestion := range e.Suggestions {
+		quoted = append(quoted, fmt.Sprintf("%q", suggestion))
+	}
+
+	return fmt.Sprintf("did you mean %s?", strings.Join(quoted, " or "))
+}
+
+// UnknownAlias returns the error for alias missing from group, suggesting
+// close aliases of the group.
+func (g *UnitGroup) UnknownAlias(alias string) *UnknownAliasError {
+	return &UnknownAliasError{
+		Alias:       alias,
+		Group:       g,
+		Suggestions: suggestAliases(alias, g.aliases),
+	}
+}
+
+// UnknownAlias returns the error for alias missing from every group of
+// registry, suggesting close aliases of all of them.
+func UnknownAlias(alias string, registry UnitRegistry) *UnknownAliasError {
+	aliases := make(UnitAliases, 256)
+
+	for _, group := range registry.Groups() {
+		maps.Copy(aliases, group.aliases)
+	}
+
+	return &UnknownAliasError{
+		Alias:       alias,
+		Suggestions: suggestAliases(alias, aliases),
+	}
+}
+
+// Locate fills Elsewhere with the units alias names in groups of registry
+// other than the one searched.
+func (e *UnknownAliasError) Locate(registry UnitRegistry) {
+	e.Elsewhere = e.Elsewhere[:0]
+
+	for key, group := range registry.Groups() {
+		if group == e.Group {
+			continue
+		}
+
+		if unit, ok := group.Get(e.Alias); ok {
+			e.Elsewhere = append(e.Elsewhere, AliasMatch{
+				Key:   key,
+				Group: group,
+				Unit:  unit,
+			})
+		}
+	}
+}
+
+// suggestAliases returns up to MaxSuggestions aliases within a small edit
+// distance of alias, the closest alias of each unit only.
+func suggestAliases(alias string, aliases UnitAliases) []string {
+	type candidate struct {
+		alias    string
+		distance int
+	}
+
+	target := []rune(foldCase(NormalizeAlias(alias)))
+	limit := min(2, max(1, len(target)/3))
+	best := make(map[*Unit]candidate)
+
+	for _, other := range slices.Sorted(maps.Keys(aliases)) {
+		distance := editDistance(target, []rune(foldCase(other)))
+		if distance > limit {
+			continue
+		}
+
+		unit := aliases[other]
+		if current, ok := best[unit]; !ok || distance < current.distance {
+			best[unit] = candidate{alias: other, distance: distance}
+		}
+	}
+
+	candidates := slices.SortedFunc(
+		maps.Values(best),
+		func(a, b candidate) int {
+			return cmp.Or(
+				cmp.Compare(a.distance, b.distance),
+				cmp.Compare(a.alias, b.alias),
+			)
+		},
+	)
+
+	suggestions := make([]string, 0, MaxSuggestions)
+	for _, c := range candidates[:min(len(candidates), MaxSuggestions)] {
+		suggestions = append(suggestions, c.alias)
+	}
+
+	return suggestions
+}
+
+// editDistance is the optimal string alignment distance: insertions,
+// deletions, substitutions and transpositions of adjacent runes cost 1.
+func editDistance(a, b []rune) int {
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(b)]
+}
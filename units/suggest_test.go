@@ -0,0 +1,102 @@
+package units
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestEditDistance(t *testing.T) {
+	testCases := []struct {
+		a, b     string
+		expected int
+	}{
+		{"", "", 0},
+		{"hour", "hour", 0},
+		{"minuts", "minutes", 1},
+		{"huor", "hour", 1},
+		{"kilometer", "kilometre", 1},
+		{"sec", "", 3},
+		{"μm", "um", 1},
+		{"day", "year", 3},
+	}
+
+	for _, tc := range testCases {
+		got := editDistance([]rune(tc.a), []rune(tc.b))
+		if got != tc.expected {
+			t.Errorf(
+				"editDistance(%q, %q) = %d, want %d",
+				tc.a,
+				tc.b,
+				got,
+				tc.expected,
+			)
+		}
+	}
+}
+
+func TestUnknownAliasSuggestions(t *testing.T) {
+	group := helperTimeGroup(t)
+
+	testCases := []struct {
+		alias    string
+		expected []string
+	}{
+		{alias: "minuts", expected: []string{"minute"}},
+		{alias: "huors", expected: []string{"hours"}},
+		{alias: "Yeers", expected: []string{"years"}},
+		{alias: "parsnip", expected: []string{}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			err := group.UnknownAlias(tc.alias)
+
+			if !slices.Equal(err.Suggestions, tc.expected) {
+				t.Errorf(
+					"Suggestions = %v, want %v",
+					err.Suggestions,
+					tc.expected,
+				)
+			}
+		})
+	}
+}
+
+func TestUnknownAliasHint(t *testing.T) {
+	_, err := ParseQuantity("5 minuts", EmbeddedUnitRegistry)
+
+	var unknown *UnknownAliasError
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownAliasError, got %v", err)
+	}
+
+	if unknown.Group != nil {
+		t.Errorf("expected registry-wide error, got group %v", unknown.Group)
+	}
+
+	if hint := unknown.Hint(); hint != `did you mean "minute"?` {
+		t.Errorf("Hint() = %q", hint)
+	}
+
+	group := helperTimeGroup(t)
+
+	_, err = group.ParseQuantity("5 km")
+	if !errors.As(err, &unknown) {
+		t.Fatalf("expected UnknownAliasError, got %v", err)
+	}
+
+	if err.Error() != "alias 'km' not found" {
+		t.Errorf("Error() = %q", err.Error())
+	}
+
+	unknown.Locate(EmbeddedUnitRegistry)
+
+	if hint := unknown.Hint(); hint != `"km" is a unit of length, not time` {
+		t.Errorf("Hint() = %q", hint)
+	}
+
+	if none := group.UnknownAlias("parsnip"); len(none.Hint()) != 0 {
+		t.Errorf("Hint() = %q, want empty", none.Hint())
+	}
+}
@@ -0,0 +1,281 @@
+package unit_entry
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Decoder reads a unit file in a single format. Decoders returned by
+// DecoderFor validate the file like ReadUnitFile does.
+type Decoder func(data io.Reader) (UnitFile, error)
+
+// ListSeparator separates aliases and systems within a CSV or TSV cell.
+const ListSeparator = "|"
+
+var decoders = struct {
+	sync.RWMutex
+	byExt map[string]Decoder
+}{
+	byExt: map[string]Decoder{
+		".json": decodeJSON,
+		".yaml": decodeYAML,
+		".yml":  decodeYAML,
+		".toml": decodeTOML,
+		".csv":  decodeDelimited(','),
+		".tsv":  decodeDelimited('\t'),
+	},
+}
+
+// RegisterDecoder makes files with extension ext, e.g. ".xml", loadable as
+// unit files. It replaces any decoder registered for ext. It is safe for
+// concurrent use.
+func RegisterDecoder(ext string, decoder Decoder) {
+	decoders.Lock()
+	defer decoders.Unlock()
+
+	decoders.byExt[strings.ToLower(ext)] = decoder
+}
+
+// DecoderFor returns the validating decoder for a file extension with the
+// leading dot, matched regardless of case.
+func DecoderFor(ext string) (Decoder, bool) {
+	decoders.RLock()
+	defer decoders.RUnlock()
+
+	decoder, ok := decoders.byExt[strings.ToLower(ext)]
+	if !ok {
+		return nil, false
+	}
+
+	return validated(decoder), true
+}
+
+// Extensions returns the sorted file extensions with a registered decoder.
+func Extensions() []string {
+	decoders.RLock()
+	defer decoders.RUnlock()
+
+	return slices.Sorted(maps.Keys(decoders.byExt))
+}
+
+func validated(decoder Decoder) Decoder {
+	return func(data io.Reader) (UnitFile, error) {
+		file, err := decoder(data)
+		if err != nil {
+			return UnitFile{}, err
+		}
+
+		if err := file.validate(); err != nil {
+			return UnitFile{}, err
+		}
+
+		return file, nil
+	}
+}
+
+// decodeYAML reads a sequence of units (version 1) or a mapping with the
+// same keys as the JSON object format. Keys are the lowercased field names.
+func decodeYAML(data io.Reader) (file UnitFile, err error) {
+	content, err := io.ReadAll(data)
+	if err != nil {
+		return file, fmt.Errorf("reading YAML: %w", err)
+	}
+
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return file, fmt.Errorf("reading YAML: %w", err)
+	}
+
+	if document.Kind != yaml.DocumentNode || len(document.Content) == 0 {
+		return file, fmt.Errorf("reading YAML: %w", io.EOF)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	if document.Content[0].Kind == yaml.SequenceNode {
+		file.Version = 1
+
+		if err := decoder.Decode(&file.Units); err != nil {
+			return UnitFile{}, fmt.Errorf("reading YAML: %w", err)
+		}
+
+		return file, nil
+	}
+
+	if err := decoder.Decode(&file); err != nil {
+		return UnitFile{}, fmt.Errorf("reading YAML: %w", err)
+	}
+
+	return file, file.checkObject()
+}
+
+// decodeTOML reads a document with a version key, an optional [group]
+// table and [[units]] tables.
+func decodeTOML(data io.Reader) (file UnitFile, err error) {
+	meta, err := toml.NewDecoder(data).Decode(&file)
+	if err != nil {
+		return UnitFile{}, fmt.Errorf("reading TOML: %w", err)
+	}
+
+	if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+		return UnitFile{}, fmt.Errorf(
+			"reading TOML: unknown field '%s'",
+			undecoded[0],
+		)
+	}
+
+	return file, file.checkObject()
+}
+
+// csvColumns sets a unit entry field from a CSV cell. Empty cells leave the
+// field unset.
+var csvColumns = map[string]func(entry *UnitEntry, cell string) error{
+	"name": func(entry *UnitEntry, cell string) error {
+		entry.Name = cell
+		return nil
+	},
+	"value": func(entry *UnitEntry, cell string) (err error) {
+		entry.Value, err = strconv.ParseFloat(cell, 64)
+		return
+	},
+	"aliases": func(entry *UnitEntry, cell string) error {
+		entry.Aliases = splitList(cell)
+		return nil
+	},
+	"systems": func(entry *UnitEntry, cell string) error {
+		entry.Systems = splitList(cell)
+		return nil
+	},
+	"symbol": func(entry *UnitEntry, cell string) error {
+		entry.Symbol = cell
+		return nil
+	},
+	"plural": func(entry *UnitEntry, cell string) error {
+		entry.Plural = cell
+		return nil
+	},
+	"description": func(entry *UnitEntry, cell string) error {
+		entry.Description = cell
+		return nil
+	},
+	"prefixable": func(entry *UnitEntry, cell string) (err error) {
+		entry.Prefixable, err = strconv.ParseBool(cell)
+		return
+	},
+	"offset": func(entry *UnitEntry, cell string) (err error) {
+		entry.Offset, err = strconv.ParseFloat(cell, 64)
+		return
+	},
+	"definition": func(entry *UnitEntry, cell string) error {
+		entry.Definition = cell
+		return nil
+	},
+}
+
+func splitList(cell string) (items []string) {
+	for item := range strings.SplitSeq(cell, ListSeparator) {
+		if item = strings.TrimSpace(item); len(item) > 0 {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// decodeDelimited reads a version 1 table whose header row names the
+// columns, e.g. "name,value,aliases". Lines starting with '#' are skipped.
+func decodeDelimited(comma rune) Decoder {
+	return func(data io.Reader) (file UnitFile, err error) {
+		reader := csv.NewReader(data)
+		reader.Comma = comma
+		reader.Comment = '#'
+
+		header, err := reader.Read()
+		if err != nil {
+			return file, fmt.Errorf("reading CSV header: %w", err)
+		}
+
+		setters, err := csvSetters(header)
+		if err != nil {
+			return file, err
+		}
+
+		file.Version = 1
+
+		for {
+			record, err := reader.Read()
+			if err == io.EOF {
+				return file, nil
+			}
+
+			if err != nil {
+				return UnitFile{}, fmt.Errorf("reading CSV: %w", err)
+			}
+
+			var entry UnitEntry
+
+			for i, cell := range record {
+				cell = strings.TrimSpace(cell)
+				if len(cell) == 0 {
+					continue
+				}
+
+				if err := setters[i](&entry, cell); err != nil {
+					line, _ := reader.FieldPos(i)
+
+					return UnitFile{}, fmt.Errorf(
+						"reading CSV: line %d, column '%s': %w",
+						line,
+						header[i],
+						err,
+					)
+				}
+			}
+
+			file.Units = append(file.Units, entry)
+		}
+	}
+}
+
+func csvSetters(
+	header []string,
+) ([]func(*UnitEntry, string) error, error) {
+	setters := make([]func(*UnitEntry, string) error, len(header))
+	seen := make(map[string]bool, len(header))
+
+	for i, column := range header {
+		// spreadsheets may prefix UTF-8 exports with a byte order mark
+		column = strings.TrimPrefix(column, "\ufeff")
+		column = strings.ToLower(strings.TrimSpace(column))
+		header[i] = column
+
+		setter, ok := csvColumns[column]
+		if !ok {
+			return nil, fmt.Errorf("unknown CSV column '%s'", column)
+		}
+
+		if seen[column] {
+			return nil, fmt.Errorf("duplicate CSV column '%s'", column)
+		}
+
+		seen[column] = true
+		setters[i] = setter
+	}
+
+	if !seen["name"] {
+		return nil, fmt.Errorf("CSV header is missing column 'name'")
+	}
+
+	return setters, nil
+}
@@ -0,0 +1,321 @@
+package unit_entry
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const unitFileV2YAML = `
+version: 2
+group:
+  name: temperature
+  base: kelvin
+  dimension: temperature
+  case: sensitive
+units:
+  - {name: kelvin, value: 1, symbol: K, plural: kelvins}
+  - name: celsius
+    value: 1
+    offset: 273.15
+    symbol: °C
+    description: degree Celsius
+    prefixable: false
+`
+
+const unitFileV2TOML = `
+version = 2
+
+[group]
+name = "temperature"
+base = "kelvin"
+dimension = "temperature"
+case = "sensitive"
+
+[[units]]
+name = "kelvin"
+value = 1
+symbol = "K"
+plural = "kelvins"
+
+[[units]]
+name = "celsius"
+value = 1
+offset = 273.15
+symbol = "°C"
+description = "degree Celsius"
+prefixable = false
+`
+
+func helperDecode(t *testing.T, ext, input string) UnitFile {
+	t.Helper()
+
+	decode, ok := DecoderFor(ext)
+	if !ok {
+		t.Fatalf("no decoder for %q", ext)
+	}
+
+	file, err := decode(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("decode(%s) error = %v", ext, err)
+	}
+
+	return file
+}
+
+func TestDecoderFor_Version2(t *testing.T) {
+	expected, err := ReadUnitFile(strings.NewReader(unitFileV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		ext   string
+		input string
+	}{
+		{ext: ".json", input: unitFileV2},
+		{ext: ".yaml", input: unitFileV2YAML},
+		{ext: ".YML", input: unitFileV2YAML},
+		{ext: ".toml", input: unitFileV2TOML},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ext, func(t *testing.T) {
+			file := helperDecode(t, tc.ext, tc.input)
+
+			if !reflect.DeepEqual(file, expected) {
+				t.Errorf("decoded %+v, want %+v", file, expected)
+			}
+		})
+	}
+}
+
+func TestDecoderFor_Version1(t *testing.T) {
+	expected := []UnitEntry{
+		{
+			Name:    "meter",
+			Value:   1,
+			Aliases: []string{"meters", "m"},
+		},
+		{
+			Name:    "foot",
+			Aliases: []string{"feet", "ft"},
+			Systems: []string{"imperial", "us"},
+			Symbol:  "′",
+			// relative entries are resolved by the units package
+			Definition: "12 inch",
+		},
+		{
+			Name:       "inch",
+			Value:      0.0254,
+			Systems:    []string{"imperial"},
+			Prefixable: true,
+		},
+	}
+
+	testCases := []struct {
+		ext   string
+		input string
+	}{
+		{
+			ext: ".csv",
+			input: "\ufeffName,Value,Aliases,Systems,Symbol," +
+				"Definition,Prefixable\n" +
+				"# comments and blank cells are skipped\n" +
+				"meter,1,meters|m,,,,\n" +
+				"foot,,feet | ft,imperial|us,′,12 inch,\n" +
+				"inch,0.0254,,imperial,,,true\n",
+		},
+		{
+			ext: ".tsv",
+			input: "name\tvalue\taliases\tsystems\tsymbol\t" +
+				"definition\tprefixable\n" +
+				"meter\t1\tmeters|m\t\t\t\t\n" +
+				"foot\t\tfeet|ft\timperial|us\t′\t12 inch\t\n" +
+				"inch\t0.0254\t\timperial\t\t\ttrue\n",
+		},
+		{
+			ext: ".yaml",
+			input: `
+- {name: meter, value: 1, aliases: [meters, m]}
+- name: foot
+  aliases: [feet, ft]
+  systems: [imperial, us]
+  symbol: ′
+  definition: 12 inch
+- {name: inch, value: 0.0254, systems: [imperial], prefixable: true}
+`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.ext, func(t *testing.T) {
+			file := helperDecode(t, tc.ext, tc.input)
+
+			if file.Version != 1 {
+				t.Errorf("expected version 1, got %d", file.Version)
+			}
+
+			if !reflect.DeepEqual(file.Units, expected) {
+				t.Errorf("decoded %+v, want %+v", file.Units, expected)
+			}
+		})
+	}
+}
+
+func TestDecoderFor_Errors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		ext     string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "csv invalid unit",
+			ext:     ".csv",
+			input:   "name,value\nmeter,0\n",
+			wantErr: "error validating unit 0 'meter': " + ErrZeroValue.Error(),
+		},
+		{
+			name:    "csv unknown column",
+			ext:     ".csv",
+			input:   "name,size\nmeter,1\n",
+			wantErr: "unknown CSV column 'size'",
+		},
+		{
+			name:    "csv duplicate column",
+			ext:     ".csv",
+			input:   "name,value,Value\nmeter,1,1\n",
+			wantErr: "duplicate CSV column 'value'",
+		},
+		{
+			name:    "csv missing name",
+			ext:     ".csv",
+			input:   "value\n1\n",
+			wantErr: "CSV header is missing column 'name'",
+		},
+		{
+			name:    "csv invalid number",
+			ext:     ".csv",
+			input:   "name,value\nmeter,1\nfoot,one\n",
+			wantErr: "reading CSV: line 3, column 'value'",
+		},
+		{
+			name:    "csv empty",
+			ext:     ".csv",
+			input:   "",
+			wantErr: "reading CSV header: EOF",
+		},
+		{
+			name:    "yaml invalid unit",
+			ext:     ".yaml",
+			input:   "- {name: meter, value: -1}",
+			wantErr: "error validating unit 0 'meter': " + ErrZeroValue.Error(),
+		},
+		{
+			name:    "yaml unknown field",
+			ext:     ".yaml",
+			input:   "- {name: meter, value: 1, size: 2}",
+			wantErr: "field size not found",
+		},
+		{
+			name:    "yaml unsupported version",
+			ext:     ".yml",
+			input:   "{version: 1, units: []}",
+			wantErr: "unsupported unit file version 1, expected 2",
+		},
+		{
+			name:    "yaml empty",
+			ext:     ".yaml",
+			input:   "# nothing",
+			wantErr: "reading YAML: EOF",
+		},
+		{
+			name: "toml unknown field",
+			ext:  ".toml",
+			input: "version = 2\n[[units]]\nname = 'm'\nvalue = 1\n" +
+				"size = 2\n",
+			wantErr: "reading TOML: unknown field 'units.size'",
+		},
+		{
+			name:    "toml missing units",
+			ext:     ".toml",
+			input:   "version = 2\n",
+			wantErr: "unit file is missing 'units'",
+		},
+		{
+			name: "toml value and definition",
+			ext:  ".toml",
+			input: "version = 2\n[[units]]\nname = 'ft'\nvalue = 1\n" +
+				"definition = '1 m'\n",
+			wantErr: "error validating unit 0 'ft': " +
+				ErrValueAndDefinition.Error(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			decode, ok := DecoderFor(tc.ext)
+			if !ok {
+				t.Fatalf("no decoder for %q", tc.ext)
+			}
+
+			_, err := decode(strings.NewReader(tc.input))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	if _, ok := DecoderFor(".units"); ok {
+		t.Fatal("unexpected decoder for .units")
+	}
+
+	RegisterDecoder(".UNITS", decodeDelimited(';'))
+	t.Cleanup(func() {
+		decoders.Lock()
+		defer decoders.Unlock()
+
+		delete(decoders.byExt, ".units")
+	})
+
+	if !slices.Contains(Extensions(), ".units") {
+		t.Errorf("Extensions() = %v, want .units", Extensions())
+	}
+
+	file := helperDecode(t, ".units", "name;value\nmeter;1\n")
+	if len(file.Units) != 1 || file.Units[0].Name != "meter" {
+		t.Errorf("unexpected units %+v", file.Units)
+	}
+
+	decode, _ := DecoderFor(".units")
+	_, err := decode(strings.NewReader("name;value\nmeter;\n"))
+	if err == nil || !strings.Contains(err.Error(), ErrZeroValue.Error()) {
+		t.Errorf("error = %v, want %v", err, ErrZeroValue)
+	}
+
+	var wg sync.WaitGroup
+
+	for range 4 {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+			RegisterDecoder(".units", decodeDelimited(';'))
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			if _, ok := DecoderFor(".units"); !ok {
+				t.Error("decoder for .units lost while registering")
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -6,31 +6,62 @@ import (
 	"fmt"
 	"io"
 	"iter"
+	"math"
 )
 
 var (
-	ErrEmptyName = errors.New("unit name cannot be empty")
-	ErrZeroValue = errors.New("unit value must be positive non-zero")
+	ErrEmptyName          = errors.New("unit name cannot be empty")
+	ErrZeroValue          = errors.New("unit value must be positive non-zero")
+	ErrNotFinite          = errors.New("unit offset must be a finite number")
+	ErrValueAndDefinition = errors.New(
+		"unit value and definition are mutually exclusive",
+	)
+	ErrPrefixableOffset = errors.New(
+		"unit with an offset cannot be prefixable",
+	)
 )
 
 // UnitEntry represents a single unit definition.
 // Fields are exported to work with json.Decoder
 type UnitEntry struct {
-	Name    string   `json:"name"`
-	Value   float64  `json:"value"`
-	Aliases []string `json:"aliases"`
+	Name        string   `json:"name"`
+	Value       float64  `json:"value"`
+	Aliases     []string `json:"aliases"`
+	Systems     []string `json:"systems"`
+	Symbol      string   `json:"symbol,omitempty"`
+	Plural      string   `json:"plural,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Prefixable  bool     `json:"prefixable,omitempty"`
+	// added to value * Value when converting to the base unit, as for
+	// temperature scales
+	Offset float64 `json:"offset,omitempty"`
+	// size relative to other units of the file, e.g. "12 inch", used
+	// instead of Value
+	Definition string `json:"definition,omitempty"`
 }
 
 func (u *UnitEntry) validate() error {
 	if len(u.Name) == 0 {
 		return ErrEmptyName
 	}
-	if u.Value <= 0 {
+	if len(u.Definition) > 0 {
+		if u.Value != 0 {
+			return ErrValueAndDefinition
+		}
+	} else if u.Value <= 0 {
 		return ErrZeroValue
 	}
+	if math.IsNaN(u.Offset) || math.IsInf(u.Offset, 0) {
+		return ErrNotFinite
+	}
+	if u.Prefixable && u.Offset != 0 {
+		return ErrPrefixableOffset
+	}
 	return nil
 }
 
+// IsBase reports whether the entry is the base unit. Entries given by a
+// definition are resolved by the units package first.
 func (u *UnitEntry) IsBase() bool {
 	return u.Value == 1.0
 }
@@ -0,0 +1,123 @@
+package unit_entry
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"unicode"
+)
+
+// CurrentVersion is the version of the object unit file format. A bare JSON
+// array of units is read as version 1.
+const CurrentVersion = 2
+
+var ErrUnsupportedVersion = errors.New("unsupported unit file version")
+
+// GroupEntry holds the group-level settings of a version 2 unit file.
+type GroupEntry struct {
+	Name        string `json:"name,omitempty"`
+	Base        string `json:"base,omitempty"`      // name of the base unit
+	Dimension   string `json:"dimension,omitempty"` // e.g. "length"
+	Description string `json:"description,omitempty"`
+	Case        string `json:"case,omitempty"` // "fold" or "sensitive"
+}
+
+// UnitFile is a unit file of any version. Version 1 files have no group
+// settings.
+type UnitFile struct {
+	Version int         `json:"version"`
+	Group   GroupEntry  `json:"group"`
+	Units   []UnitEntry `json:"units"`
+}
+
+// ReadUnitFile reads either a bare array of units (version 1) or an object
+// {"version": 2, "group": {...}, "units": [...]}.
+func ReadUnitFile(jsonData io.Reader) (file UnitFile, err error) {
+	return validated(decodeJSON)(jsonData)
+}
+
+func decodeJSON(jsonData io.Reader) (file UnitFile, err error) {
+	reader := bufio.NewReader(jsonData)
+
+	first, err := peekNonSpace(reader)
+	if err != nil {
+		return file, fmt.Errorf("reading JSON: %w", err)
+	}
+
+	if first != '{' {
+		file.Version = 1
+
+		for entry, err := range IterUnitEntries(reader) {
+			if err != nil {
+				return UnitFile{}, err
+			}
+
+			file.Units = append(file.Units, entry)
+		}
+
+		return file, nil
+	}
+
+	decoder := json.NewDecoder(reader)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&file); err != nil {
+		return UnitFile{}, fmt.Errorf("reading JSON: %w", err)
+	}
+
+	return file, file.checkObject()
+}
+
+// checkObject checks the version and units of a file decoded from an
+// object, as opposed to a bare list of units.
+func (f *UnitFile) checkObject() error {
+	if f.Version != CurrentVersion {
+		return fmt.Errorf(
+			"%w %d, expected %d",
+			ErrUnsupportedVersion,
+			f.Version,
+			CurrentVersion,
+		)
+	}
+
+	if f.Units == nil {
+		return fmt.Errorf("unit file is missing 'units'")
+	}
+
+	return nil
+}
+
+// validate checks every unit entry of a decoded file.
+func (f *UnitFile) validate() error {
+	for i := range f.Units {
+		if err := f.Units[i].validate(); err != nil {
+			return fmt.Errorf(
+				"error validating unit %d '%s': %w",
+				i,
+				f.Units[i].Name,
+				err,
+			)
+		}
+	}
+
+	return nil
+}
+
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		next, err := reader.Peek(1)
+		if err != nil {
+			return 0, err
+		}
+
+		if !unicode.IsSpace(rune(next[0])) {
+			return next[0], nil
+		}
+
+		if _, err := reader.Discard(1); err != nil {
+			return 0, err
+		}
+	}
+}
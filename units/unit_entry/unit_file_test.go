@@ -0,0 +1,150 @@
+package unit_entry
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/grzadr/refscaler/internal"
+)
+
+const unitFileV2 = `{
+	"version": 2,
+	"group": {
+		"name": "temperature",
+		"base": "kelvin",
+		"dimension": "temperature",
+		"case": "sensitive"
+	},
+	"units": [
+		{"name": "kelvin", "value": 1, "symbol": "K", "plural": "kelvins"},
+		{
+			"name": "celsius",
+			"value": 1,
+			"offset": 273.15,
+			"symbol": "°C",
+			"description": "degree Celsius",
+			"prefixable": false
+		}
+	]
+}`
+
+func TestReadUnitFile_Version1(t *testing.T) {
+	file, err := ReadUnitFile(
+		strings.NewReader(internal.GetFixtureUnitEntriesStr()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if file.Version != 1 {
+		t.Errorf("expected version 1, got %d", file.Version)
+	}
+
+	entries := internal.GetFixtureTestUnitEntries()
+	if len(file.Units) != len(entries) {
+		t.Fatalf("expected %d units, got %d", len(entries), len(file.Units))
+	}
+
+	for i := range entries {
+		if !helpCompareUnitEntry(&file.Units[i], &entries[i]) {
+			t.Errorf(
+				"unit %d: expected %+v, got %+v",
+				i,
+				entries[i],
+				file.Units[i],
+			)
+		}
+	}
+}
+
+func TestReadUnitFile_Version2(t *testing.T) {
+	file, err := ReadUnitFile(strings.NewReader(unitFileV2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := GroupEntry{
+		Name:      "temperature",
+		Base:      "kelvin",
+		Dimension: "temperature",
+		Case:      "sensitive",
+	}
+
+	if file.Version != 2 || file.Group != expected {
+		t.Errorf("unexpected header %d %+v", file.Version, file.Group)
+	}
+
+	if len(file.Units) != 2 {
+		t.Fatalf("expected 2 units, got %d", len(file.Units))
+	}
+
+	celsius := file.Units[1]
+	if celsius.Offset != 273.15 || celsius.Symbol != "°C" ||
+		celsius.Description != "degree Celsius" {
+		t.Errorf("unexpected unit %+v", celsius)
+	}
+}
+
+func TestReadUnitFile_Errors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{
+			name:    "unsupported version",
+			input:   `{"version": 3, "units": []}`,
+			wantErr: "unsupported unit file version 3, expected 2",
+		},
+		{
+			name:    "unknown field",
+			input:   `{"version": 2, "units": [], "extra": 1}`,
+			wantErr: `json: unknown field "extra"`,
+		},
+		{
+			name:    "missing units",
+			input:   `{"version": 2}`,
+			wantErr: "unit file is missing 'units'",
+		},
+		{
+			name:    "invalid unit",
+			input:   `{"version": 2, "units": [{"name": "x", "value": 0}]}`,
+			wantErr: "error validating unit 0 'x': " + ErrZeroValue.Error(),
+		},
+		{
+			name: "value and definition",
+			input: `{"version": 2, "units": [
+				{"name": "foot", "value": 0.3, "definition": "12 inch"}
+			]}`,
+			wantErr: ErrValueAndDefinition.Error(),
+		},
+		{
+			name: "prefixable offset",
+			input: `{"version": 2, "units": [
+				{"name": "celsius", "value": 1, "offset": 273.15,
+					"prefixable": true}
+			]}`,
+			wantErr: ErrPrefixableOffset.Error(),
+		},
+		{
+			name:    "empty input",
+			input:   "  ",
+			wantErr: "reading JSON: EOF",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ReadUnitFile(strings.NewReader(tc.input))
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+
+	_, err := ReadUnitFile(strings.NewReader(`{"version": 1, "units": []}`))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("expected ErrUnsupportedVersion, got %v", err)
+	}
+}
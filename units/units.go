@@ -1,6 +1,7 @@
 package units
 
 import (
+	"cmp"
 	"embed"
 	"encoding/json"
 	"fmt"
@@ -8,15 +9,75 @@ import (
 	"io/fs"
 	"iter"
 	"maps"
+	"math/big"
 	"slices"
+	"strings"
+	"sync"
 
 	"github.com/grzadr/refscaler/units/unit_entry"
 	"github.com/grzadr/refscaler/walkentry"
 )
 
 type Unit struct {
-	Name       string
-	Multiplier float64
+	Name        string
+	Multiplier  float64
+	Systems     []string // empty when the unit belongs to every system
+	Symbol      string
+	Plural      string
+	Description string
+	Prefixable  bool
+	Offset      float64  // added after Multiplier when converting to base
+	exact       *big.Rat // multiplier as resolved from the unit file
+	group       *UnitGroup
+}
+
+// Group returns the unit group the unit was loaded into.
+func (u *Unit) Group() *UnitGroup {
+	return u.group
+}
+
+// ExactMultiplier returns the multiplier as an exact rational number. Units
+// loaded from a file keep the value computed from its decimals and
+// definitions, so "12 inch" is exactly 3048/10000. Other units use the
+// shortest decimal form of Multiplier, so 0.0254 is 254/10000 rather than its
+// binary approximation.
+func (u *Unit) ExactMultiplier() *big.Rat {
+	if u.exact != nil {
+		return new(big.Rat).Set(u.exact)
+	}
+
+	return decimalRat(u.Multiplier)
+}
+
+// HasOffset reports whether converting the unit to base units adds an
+// offset, as for degrees Celsius. Values in such units cannot be summed, so
+// they are rejected in measures of several terms and in enlistments.
+func (u *Unit) HasOffset() bool {
+	return u.Offset != 0
+}
+
+func (u *Unit) toBase(value float64) float64 {
+	return value*u.Multiplier + u.Offset
+}
+
+func (u *Unit) fromBase(base float64) float64 {
+	return (base - u.Offset) / u.Multiplier
+}
+
+// InSystems reports whether the unit belongs to any of systems. Units without
+// systems belong to all of them.
+func (u *Unit) InSystems(systems ...string) bool {
+	if len(u.Systems) == 0 || len(systems) == 0 {
+		return true
+	}
+
+	for _, system := range systems {
+		if slices.Contains(u.Systems, system) {
+			return true
+		}
+	}
+
+	return false
 }
 
 type (
@@ -24,26 +85,81 @@ type (
 	UnitAliases map[string]*Unit
 )
 
+// IsBase reports whether the unit is the base unit of its group.
+func (u *Unit) IsBase() bool {
+	return u.Multiplier == 1.0
+}
+
 type UnitGroup struct {
-	units   UnitsSlice
-	aliases UnitAliases
-	// allowPrefix bool // TODO
+	name        string
+	dimension   string
+	description string
+	base        *Unit // declared base unit, nil to use the one with value 1
+	units       UnitsSlice
+	aliases     UnitAliases // keyed by NormalizeAlias
+	// keyed by foldCase of aliases, nil where the folded form is ambiguous
+	folded     UnitAliases
+	casePolicy CasePolicy
+	// aliases overwritten by a later unit, reported by Validate
+	shadowed []shadowedAlias
 	// baseUnit *Unit
 }
 
-func (g *UnitGroup) add(entry unit_entry.UnitEntry) error {
+type shadowedAlias struct {
+	alias    string
+	previous *Unit
+	unit     *Unit
+}
+
+func (g *UnitGroup) setAlias(alias string, unit *Unit) {
+	alias = NormalizeAlias(alias)
+
+	if previous, ok := g.aliases[alias]; ok && previous != unit {
+		g.shadowed = append(g.shadowed, shadowedAlias{
+			alias:    alias,
+			previous: previous,
+			unit:     unit,
+		})
+	}
+
+	g.aliases[alias] = unit
+}
+
+func (g *UnitGroup) add(entry unit_entry.UnitEntry, exact *big.Rat) error {
 	unit := &Unit{
-		Name:       entry.Name,
-		Multiplier: entry.Value,
+		Name:        entry.Name,
+		Multiplier:  entry.Value,
+		Systems:     slices.Clone(entry.Systems),
+		Symbol:      entry.Symbol,
+		Plural:      entry.Plural,
+		Description: entry.Description,
+		Prefixable:  entry.Prefixable,
+		Offset:      entry.Offset,
+		exact:       exact,
+		group:       g,
 	}
 
 	g.units = append(g.units, unit)
-	g.aliases[unit.Name] = unit
+	g.setAlias(unit.Name, unit)
 
 	for _, a := range entry.Aliases {
-		g.aliases[a] = unit
+		g.setAlias(a, unit)
+	}
+
+	for _, a := range []string{entry.Symbol, entry.Plural} {
+		if len(a) > 0 {
+			g.setAlias(a, unit)
+		}
 	}
 
+	// prefixed symbols are only known once the unprefixed alias is added
+	g.rebuildFolded()
+	g.sortUnits()
+
+	return nil
+}
+
+func (g *UnitGroup) sortUnits() {
 	slices.SortFunc(g.units, func(a *Unit, b *Unit) int {
 		if a.Multiplier < b.Multiplier {
 			return -1
@@ -53,13 +169,103 @@ func (g *UnitGroup) add(entry unit_entry.UnitEntry) error {
 			return 0
 		}
 	})
-
-	return nil
 }
 
+// Get resolves alias after Unicode normalization and, unless the group is
+// CaseSensitive, regardless of case where that is unambiguous. Aliases not
+// in the group are tried as an SI prefix applied to a prefixable unit.
 func (g *UnitGroup) Get(alias string) (unit *Unit, ok bool) {
-	unit, ok = g.aliases[alias]
-	return
+	if unit, ok = g.aliases[alias]; ok {
+		return
+	}
+
+	alias = NormalizeAlias(alias)
+
+	if unit, ok = g.aliases[alias]; ok {
+		return
+	}
+
+	if g.casePolicy != CaseSensitive {
+		if unit = g.folded[foldCase(alias)]; unit != nil {
+			return unit, true
+		}
+	}
+
+	return g.prefixed(alias)
+}
+
+// prefixed resolves alias as an SI prefix applied to a prefixable unit, by
+// symbol as in "kg" or by name as in "kilograms". The unit is derived on
+// every call and never added to the group, so decompositions keep to the
+// units of the file.
+func (g *UnitGroup) prefixed(alias string) (*Unit, bool) {
+	for _, prefix := range siPrefixes {
+		if rest, ok := strings.CutPrefix(alias, prefix.symbol); ok {
+			if unit, ok := g.aliases[rest]; ok && unit.Prefixable &&
+				rest == unit.Symbol {
+				return unit.withPrefix(prefix), true
+			}
+		}
+
+		if rest, ok := strings.CutPrefix(alias, prefix.name); ok {
+			if unit, ok := g.aliases[rest]; ok && unit.Prefixable &&
+				(rest == unit.Name || rest == unit.Plural) {
+				return unit.withPrefix(prefix), true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// prefixedAliases lists every alias prefixed may resolve in the group, such as
+// "km" and "kilometers" for a prefixable meter, for IndexedRegistry.
+func (g *UnitGroup) prefixedAliases() []string {
+	aliases := make(map[string]bool)
+
+	for _, unit := range g.units {
+		if !unit.Prefixable {
+			continue
+		}
+
+		for _, prefix := range siPrefixes {
+			aliases[prefix.name+unit.Name] = true
+
+			if len(unit.Symbol) > 0 {
+				aliases[prefix.symbol+unit.Symbol] = true
+			}
+
+			if len(unit.Plural) > 0 {
+				aliases[prefix.name+unit.Plural] = true
+			}
+		}
+	}
+
+	return slices.Sorted(maps.Keys(aliases))
+}
+
+func (u *Unit) withPrefix(prefix siPrefix) *Unit {
+	exact := u.ExactMultiplier()
+	exact.Mul(exact, decimalRat(prefix.factor))
+	multiplier, _ := exact.Float64()
+
+	prefixed := &Unit{
+		Name:       prefix.name + u.Name,
+		Multiplier: multiplier,
+		Systems:    u.Systems,
+		exact:      exact,
+		group:      u.group,
+	}
+
+	if len(u.Symbol) > 0 {
+		prefixed.Symbol = prefix.symbol + u.Symbol
+	}
+
+	if len(u.Plural) > 0 {
+		prefixed.Plural = prefix.name + u.Plural
+	}
+
+	return prefixed
 }
 
 func (g *UnitGroup) IterBackward() iter.Seq[*Unit] {
@@ -76,10 +282,80 @@ func (g *UnitGroup) Length() int {
 	return len(g.units)
 }
 
+// Base returns the base unit declared by the unit file or the unit with
+// multiplier 1.
+func (g *UnitGroup) Base() (unit *Unit, ok bool) {
+	if g.base != nil {
+		return g.base, true
+	}
+
+	for _, u := range g.units {
+		if u.IsBase() {
+			return u, true
+		}
+	}
+
+	return nil, false
+}
+
+// Name returns the name declared by the unit file or the key the group was
+// registered under, e.g. "time".
+func (g *UnitGroup) Name() string {
+	return g.name
+}
+
+// Dimension returns the physical dimension declared by the unit file, e.g.
+// "length", or an empty string.
+func (g *UnitGroup) Dimension() string {
+	return g.dimension
+}
+
+func (g *UnitGroup) Description() string {
+	return g.description
+}
+
+// FilterSystems returns the units belonging to any of systems, smallest first.
+// Units without systems are always included and aliases of all units still
+// resolve through Get.
+func (g *UnitGroup) FilterSystems(systems ...string) UnitsSlice {
+	slice := make(UnitsSlice, 0, len(g.units))
+
+	for _, u := range g.units {
+		if u.InSystems(systems...) {
+			slice = append(slice, u)
+		}
+	}
+
+	return slice
+}
+
+// Systems lists the unit systems used in the group in sorted order.
+func (g *UnitGroup) Systems() []string {
+	systems := make([]string, 0, 4)
+
+	for _, u := range g.units {
+		for _, system := range u.Systems {
+			if !slices.Contains(systems, system) {
+				systems = append(systems, system)
+			}
+		}
+	}
+
+	slices.Sort(systems)
+
+	return systems
+}
+
 type UnitJSON struct {
-	Name    string   `json:"name"`
-	Value   float64  `json:"value"`
-	Aliases []string `json:"aliases"`
+	Name        string   `json:"name"`
+	Value       float64  `json:"value"`
+	Aliases     []string `json:"aliases"`
+	Systems     []string `json:"systems,omitempty"`
+	Symbol      string   `json:"symbol,omitempty"`
+	Plural      string   `json:"plural,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Prefixable  bool     `json:"prefixable,omitempty"`
+	Offset      float64  `json:"offset,omitempty"`
 }
 
 func (u *UnitJSON) AddAlias(alias string) {
@@ -94,9 +370,15 @@ func (g *UnitGroup) Serialize() UnitGroupJSON {
 
 	for _, unit := range g.units {
 		temp := UnitJSON{
-			Name:    unit.Name,
-			Value:   unit.Multiplier,
-			Aliases: make([]string, 0, 4),
+			Name:        unit.Name,
+			Value:       unit.Multiplier,
+			Aliases:     make([]string, 0, 4),
+			Systems:     unit.Systems,
+			Symbol:      unit.Symbol,
+			Plural:      unit.Plural,
+			Description: unit.Description,
+			Prefixable:  unit.Prefixable,
+			Offset:      unit.Offset,
 		}
 		json_units = append(json_units, temp)
 
@@ -106,7 +388,8 @@ func (g *UnitGroup) Serialize() UnitGroupJSON {
 	for alias, unit := range g.aliases {
 		name := unit.Name
 
-		if alias == unit.Name {
+		if alias == unit.Name || alias == unit.Symbol ||
+			alias == unit.Plural {
 			continue
 		}
 
@@ -124,16 +407,36 @@ func newUnitGroupDefault() *UnitGroup {
 	return &UnitGroup{
 		units:   make(UnitsSlice, 0, 32),
 		aliases: make(UnitAliases, 128),
+		folded:  make(UnitAliases, 128),
 	}
 }
 
+// NewUnitGroup reads a JSON unit file of any version supported by
+// unit_entry.ReadUnitFile.
 func NewUnitGroup(unitsData io.Reader) (group *UnitGroup, err error) {
+	return NewUnitGroupFrom(unitsData, unit_entry.ReadUnitFile)
+}
+
+// NewUnitGroupFrom reads a unit file with decode, e.g. a decoder returned by
+// unit_entry.DecoderFor.
+func NewUnitGroupFrom(
+	unitsData io.Reader,
+	decode unit_entry.Decoder,
+) (group *UnitGroup, err error) {
 	group = newUnitGroupDefault()
-	for entry, err := range unit_entry.IterUnitEntries(unitsData) {
-		if err != nil {
-			return group, fmt.Errorf("error reading unit entry: %s", err)
-		}
-		if err := group.add(entry); err != nil {
+
+	file, err := decode(unitsData)
+	if err != nil {
+		return group, fmt.Errorf("error reading unit entry: %s", err)
+	}
+
+	exact, err := resolveDefinitions(file.Units)
+	if err != nil {
+		return group, fmt.Errorf("error resolving unit entry: %w", err)
+	}
+
+	for i, entry := range file.Units {
+		if err := group.add(entry, exact[i]); err != nil {
 			return group, fmt.Errorf(
 				"error adding unit entry %v: %s",
 				entry,
@@ -141,13 +444,85 @@ func NewUnitGroup(unitsData io.Reader) (group *UnitGroup, err error) {
 			)
 		}
 	}
+
+	if err := group.applyHeader(file.Group); err != nil {
+		return group, fmt.Errorf("error reading unit group: %w", err)
+	}
+
 	return group, nil
 }
 
-type UnitRegistryJSON map[string]UnitGroupJSON
+func (g *UnitGroup) applyHeader(header unit_entry.GroupEntry) error {
+	g.name = header.Name
+	g.dimension = header.Dimension
+	g.description = header.Description
+
+	if len(header.Case) > 0 {
+		policy, err := ParseCasePolicy(header.Case)
+		if err != nil {
+			return err
+		}
+
+		g.casePolicy = policy
+	}
+
+	if len(header.Base) > 0 {
+		base, ok := g.unitByName(header.Base)
+		if !ok {
+			return fmt.Errorf("base unit '%s' not defined", header.Base)
+		}
+
+		if !base.IsBase() {
+			return fmt.Errorf(
+				"base unit '%s' must have value 1, not %g",
+				header.Base,
+				base.Multiplier,
+			)
+		}
+
+		g.base = base
+	}
+
+	return nil
+}
+
+// UnitFileJSON is a group serialized as a version 2 unit file.
+type UnitFileJSON struct {
+	Version int                   `json:"version"`
+	Group   unit_entry.GroupEntry `json:"group"`
+	Units   UnitGroupJSON         `json:"units"`
+}
+
+// SerializeFile returns the group with its header, so it reads back as the
+// same group.
+func (g *UnitGroup) SerializeFile() UnitFileJSON {
+	header := unit_entry.GroupEntry{
+		Name:        g.name,
+		Dimension:   g.dimension,
+		Description: g.description,
+	}
+
+	if base, ok := g.Base(); ok {
+		header.Base = base.Name
+	}
+
+	if g.casePolicy != CaseFoldUnambiguous {
+		header.Case = g.casePolicy.String()
+	}
+
+	return UnitFileJSON{
+		Version: unit_entry.CurrentVersion,
+		Group:   header,
+		Units:   g.Serialize(),
+	}
+}
+
+type UnitRegistryJSON map[string]UnitFileJSON
 
 type UnitRegistry interface {
 	Find(alias string) (group *UnitGroup, ok bool)
+	Get(key string) (group *UnitGroup, ok bool)
+	Groups() iter.Seq2[string, *UnitGroup]
 	Add(key string, group *UnitGroup)
 	Serialize() UnitRegistryJSON
 	ToJSON() (string, error)
@@ -160,15 +535,45 @@ func NewUnitRegistryFilesDefault() UnitRegistryFiles {
 }
 
 func (r *UnitRegistryFiles) Length() int {
+	r.load()
+
 	return len(*r)
 }
 
 func (r *UnitRegistryFiles) Add(key string, group *UnitGroup) {
+	r.load()
+
+	if len(group.name) == 0 {
+		group.name = key
+	}
+
 	(*r)[key] = group
 }
 
+func (r *UnitRegistryFiles) Get(key string) (group *UnitGroup, ok bool) {
+	r.load()
+
+	group, ok = (*r)[key]
+	return
+}
+
+// Groups yields the registered groups ordered by key.
+func (r *UnitRegistryFiles) Groups() iter.Seq2[string, *UnitGroup] {
+	r.load()
+
+	return func(yield func(string, *UnitGroup) bool) {
+		for _, key := range slices.Sorted(maps.Keys(*r)) {
+			if !yield(key, (*r)[key]) {
+				return
+			}
+		}
+	}
+}
+
+// Find returns the first group, ordered by key, defining alias. It scans
+// every group; wrap the registry in an IndexedRegistry for repeated lookups.
 func (r *UnitRegistryFiles) Find(alias string) (group *UnitGroup, ok bool) {
-	for group = range maps.Values(*r) {
+	for _, group = range r.Groups() {
 		_, ok = group.Get(alias)
 
 		if ok {
@@ -180,10 +585,12 @@ func (r *UnitRegistryFiles) Find(alias string) (group *UnitGroup, ok bool) {
 }
 
 func (r *UnitRegistryFiles) Serialize() UnitRegistryJSON {
-	serialized := make(map[string]UnitGroupJSON, len(*r))
+	r.load()
+
+	serialized := make(UnitRegistryJSON, len(*r))
 
 	for name, group := range *r {
-		serialized[name] = group.Serialize()
+		serialized[name] = group.SerializeFile()
 	}
 
 	return serialized
@@ -198,11 +605,12 @@ func (r *UnitRegistryFiles) ToJSON() (string, error) {
 	return string(str), nil
 }
 
-func loadUnitGroupFromJsonFile(
+func loadUnitGroupFromFile(
 	fsys fs.FS,
-	json_path string,
+	file_path string,
+	decode unit_entry.Decoder,
 ) (group *UnitGroup, err error) {
-	file, err := fsys.Open(json_path)
+	file, err := fsys.Open(file_path)
 	if err != nil {
 		return group, err
 	}
@@ -214,7 +622,11 @@ func loadUnitGroupFromJsonFile(
 		}
 	}()
 
-	group, err = NewUnitGroup(file)
+	group, err = NewUnitGroupFrom(file, decode)
+	if err != nil {
+		err = fmt.Errorf("'%s': %w", file_path, err)
+	}
+
 	return
 }
 
@@ -223,17 +635,27 @@ func NewUnitRegistryFiles(
 	dir_path string,
 ) (registry UnitRegistryFiles, err error) {
 	registry = NewUnitRegistryFilesDefault()
+	sources := make(map[string]string)
 
 	for walk_entry, err := range walkentry.WalkFS(fsys, dir_path) {
 		if err != nil {
 			return registry, err
 		}
 
-		if !walk_entry.IsJSONFile() {
+		if !walk_entry.IsRegular {
 			continue
 		}
 
-		unit_group, err := loadUnitGroupFromJsonFile(fsys, walk_entry.Path)
+		decode, ok := unit_entry.DecoderFor(walk_entry.Ext)
+		if !ok {
+			continue
+		}
+
+		unit_group, err := loadUnitGroupFromFile(
+			fsys,
+			walk_entry.Path,
+			decode,
+		)
 		if err != nil {
 			return registry, err
 		}
@@ -242,7 +664,19 @@ func NewUnitRegistryFiles(
 			continue
 		}
 
-		registry.Add(walk_entry.Name, unit_group)
+		key := cmp.Or(unit_group.name, walk_entry.Name)
+
+		if previous, ok := sources[key]; ok {
+			return registry, fmt.Errorf(
+				"'%s': unit group '%s' already loaded from '%s'",
+				walk_entry.Path,
+				key,
+				previous,
+			)
+		}
+
+		sources[key] = walk_entry.Path
+		registry.Add(key, unit_group)
 	}
 
 	return registry, err
@@ -253,17 +687,49 @@ var unitsFS embed.FS
 
 const UNITS_PATH = "units_db"
 
-var EmbeddedUnitRegistry *UnitRegistryFiles = nil
+// UnitFileSchema is the JSON Schema describing unit files of every version.
+//
+//go:embed units.schema.json
+var UnitFileSchema []byte
 
 func newEmbeddedUnitRegistry() (registry UnitRegistryFiles, err error) {
 	return NewUnitRegistryFiles(unitsFS, UNITS_PATH)
 }
 
-func init() {
-	registry, err := newEmbeddedUnitRegistry()
-	if err != nil {
-		panic(err)
-	}
+// embeddedFiles holds the embedded units once DefaultRegistry loaded them.
+var (
+	embeddedFiles UnitRegistryFiles
+	embeddedErr   error
+	embeddedOnce  sync.Once
+)
 
-	EmbeddedUnitRegistry = &registry
+// DefaultRegistry returns the units embedded from units_db. They are loaded
+// on first use and shared by all callers. On error the groups loaded so far
+// are returned together with the error.
+func DefaultRegistry() (*UnitRegistryFiles, error) {
+	embeddedOnce.Do(func() {
+		embeddedFiles, embeddedErr = newEmbeddedUnitRegistry()
+		if embeddedErr != nil {
+			embeddedErr = fmt.Errorf(
+				"failed to load embedded units: %w",
+				embeddedErr,
+			)
+		}
+	})
+
+	return &embeddedFiles, embeddedErr
+}
+
+// EmbeddedUnitRegistry points to the registry returned by DefaultRegistry.
+// Its methods load the embedded units on first use but do not report load
+// errors.
+//
+// Deprecated: Use DefaultRegistry.
+var EmbeddedUnitRegistry = &embeddedFiles
+
+// load fills EmbeddedUnitRegistry before it is read through r.
+func (r *UnitRegistryFiles) load() {
+	if r == EmbeddedUnitRegistry {
+		_, _ = DefaultRegistry()
+	}
 }
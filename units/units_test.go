@@ -1,12 +1,20 @@
 package units
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"math/big"
+	"reflect"
 	"slices"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/grzadr/refscaler/internal"
+	"github.com/grzadr/refscaler/units/unit_entry"
 )
 
 func mapKeysToString[T any](m map[string]T) string {
@@ -158,8 +166,10 @@ func TestNewUnitRegistryFiles(t *testing.T) {
 	}
 
 	expected_substr := []string{
-		"\"empty\": []",
-		"\"test_unit\": [",
+		"\"empty\": {",
+		"\"test_unit\": {",
+		"\"version\": 2,",
+		"\"units\": []",
 		"\"name\": \"meter\",\n",
 	}
 
@@ -173,3 +183,444 @@ func TestNewUnitRegistryFiles(t *testing.T) {
 		}
 	}
 }
+
+func TestNewUnitRegistryFilesFormats(t *testing.T) {
+	fsys := fstest.MapFS{
+		"units/length.csv": {Data: []byte(
+			"name,value,aliases\nmeter,1,meters|m\nfoot,0.3048,ft\n",
+		)},
+		"units/time.yaml": {Data: []byte(
+			"- {name: second, value: 1, aliases: [s]}\n" +
+				"- {name: minute, definition: 60 s}\n",
+		)},
+		"units/mass.TOML": {Data: []byte(
+			"version = 2\n[group]\nname = 'weight'\n" +
+				"[[units]]\nname = 'gram'\nvalue = 1\n",
+		)},
+		"units/README.md": {Data: []byte("# not a unit file")},
+	}
+
+	registry, err := NewUnitRegistryFiles(fsys, "units")
+	if err != nil {
+		t.Fatalf("NewUnitRegistryFiles() error = %v", err)
+	}
+
+	if keys := mapKeysToString(registry); keys != "length, time, weight" {
+		t.Fatalf("expected keys 'length, time, weight', got '%s'", keys)
+	}
+
+	expected := []struct {
+		group string
+		alias string
+		value float64
+	}{
+		{group: "length", alias: "ft", value: 0.3048},
+		{group: "time", alias: "minute", value: 60},
+		{group: "weight", alias: "gram", value: 1},
+	}
+
+	for _, tc := range expected {
+		unit, ok := registry[tc.group].Get(tc.alias)
+		if !ok || unit.Multiplier != tc.value {
+			t.Errorf(
+				"%s '%s' = %v, want %v",
+				tc.group,
+				tc.alias,
+				unit,
+				tc.value,
+			)
+		}
+	}
+
+	fsys["units/length.csv"] = &fstest.MapFile{
+		Data: []byte("name,value\nmeter,1\nfoot,\n"),
+	}
+
+	_, err = NewUnitRegistryFiles(fsys, "units")
+	wantErr := "'units/length.csv': error reading unit entry: " +
+		"error validating unit 1 'foot'"
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Errorf("error = %v, want %q", err, wantErr)
+	}
+}
+
+func TestNewUnitRegistryFilesDuplicateGroups(t *testing.T) {
+	testCases := []struct {
+		name    string
+		fsys    fstest.MapFS
+		wantErr string
+	}{
+		{
+			name: "same file name",
+			fsys: fstest.MapFS{
+				"units/length.csv": {Data: []byte("name,value\nmeter,1\n")},
+				"units/length.json": {Data: []byte(
+					`[{"name": "foot", "value": 0.3048}]`,
+				)},
+			},
+			wantErr: "'units/length.json': unit group 'length' " +
+				"already loaded from 'units/length.csv'",
+		},
+		{
+			name: "same group name",
+			fsys: fstest.MapFS{
+				"units/a.json": {Data: []byte(`{"version": 2,
+					"group": {"name": "mass"},
+					"units": [{"name": "gram", "value": 1}]}`)},
+				"units/b.yaml": {Data: []byte(
+					"version: 2\ngroup: {name: mass}\n" +
+						"units: [{name: pound, value: 453.59237}]\n",
+				)},
+			},
+			wantErr: "'units/b.yaml': unit group 'mass' " +
+				"already loaded from 'units/a.json'",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewUnitRegistryFiles(tc.fsys, "units")
+			if err == nil || err.Error() != tc.wantErr {
+				t.Errorf("error = %v, want %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnitGroupFilterSystems(t *testing.T) {
+	group, ok := EmbeddedUnitRegistry.Get("length")
+	if !ok {
+		t.Fatal("expected embedded 'length' group")
+	}
+
+	if systems := strings.Join(group.Systems(), ", "); systems !=
+		"astronomical, imperial, metric, nautical, us" {
+		t.Fatalf("unexpected systems '%s'", systems)
+	}
+
+	for _, unit := range group.FilterSystems("metric") {
+		if !slices.Contains(unit.Systems, "metric") {
+			t.Fatalf("unit '%s' is not metric: %v", unit.Name, unit.Systems)
+		}
+	}
+
+	imperial := group.FilterSystems("imperial", "astronomical")
+	if len(imperial) != 10 {
+		t.Fatalf(
+			"expected 10 imperial and astronomical units, got %d",
+			len(imperial),
+		)
+	}
+
+	if all := group.FilterSystems(); len(all) != group.Length() {
+		t.Fatalf("expected all %d units, got %d", group.Length(), len(all))
+	}
+
+	if _, ok := group.Get("ft"); !ok {
+		t.Fatal("expected aliases of every system to resolve")
+	}
+
+	untagged := Unit{Name: "second", Multiplier: 1}
+	if !untagged.InSystems("metric") {
+		t.Fatal("expected unit without systems to belong to every system")
+	}
+}
+
+func TestUnitExactMultiplier(t *testing.T) {
+	unit := Unit{Name: "inch", Multiplier: 0.0254}
+
+	if exp := big.NewRat(127, 5000); unit.ExactMultiplier().Cmp(exp) != 0 {
+		t.Fatalf(
+			"expected exact multiplier %s, got %s",
+			exp,
+			unit.ExactMultiplier(),
+		)
+	}
+}
+
+func TestDefaultRegistry(t *testing.T) {
+	registry, err := DefaultRegistry()
+	if err != nil {
+		t.Fatalf("failed to load default registry: %s", err)
+	}
+
+	if again, _ := DefaultRegistry(); again != registry {
+		t.Fatal("expected DefaultRegistry to be loaded only once")
+	}
+
+	if keys := mapKeysToString(*registry); keys != "length, time" {
+		t.Fatalf("expected keys 'length, time', got '%s'", keys)
+	}
+
+	group, _ := registry.Get("time")
+
+	if embedded, ok := EmbeddedUnitRegistry.Get("time"); !ok ||
+		embedded != group {
+		t.Fatal("expected EmbeddedUnitRegistry to share the default groups")
+	}
+
+	if EmbeddedUnitRegistry.Length() != registry.Length() {
+		t.Fatalf(
+			"expected EmbeddedUnitRegistry with %d groups, got %d",
+			registry.Length(),
+			EmbeddedUnitRegistry.Length(),
+		)
+	}
+}
+
+const temperatureUnits = `{
+	"version": 2,
+	"group": {
+		"name": "temperature",
+		"base": "kelvin",
+		"dimension": "temperature",
+		"case": "sensitive"
+	},
+	"units": [
+		{"name": "kelvin", "value": 1, "symbol": "K"},
+		{
+			"name": "celsius",
+			"value": 1,
+			"offset": 273.15,
+			"symbol": "C"
+		},
+		{
+			"name": "fahrenheit",
+			"value": 0.5555555555555556,
+			"offset": 255.3722222222222,
+			"plural": "fahrenheits"
+		}
+	]
+}`
+
+func TestNewUnitGroupVersion2(t *testing.T) {
+	registry, err := NewUnitRegistryFiles(fstest.MapFS{
+		"units/temp.json": {Data: []byte(temperatureUnits)},
+	}, "units")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group, ok := registry.Get("temperature")
+	if !ok {
+		t.Fatalf("expected group registered under its declared name")
+	}
+
+	if base, _ := group.Base(); base.Name != "kelvin" {
+		t.Errorf("expected declared base 'kelvin', got '%s'", base.Name)
+	}
+
+	if group.Dimension() != "temperature" ||
+		group.CasePolicy() != CaseSensitive {
+		t.Errorf("unexpected group settings %+v", group)
+	}
+
+	if _, ok := group.Get("k"); ok {
+		t.Error("expected case sensitive group to reject 'k'")
+	}
+
+	celsius, _ := group.Get("C")
+	fahrenheit, _ := group.Get("fahrenheits")
+
+	converted, err := NewQuantity(100, celsius).Convert(fahrenheit)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(converted.Value-212) > 1e-9 {
+		t.Errorf("expected 100 C to be 212 F, got %g", converted.Value)
+	}
+
+	for _, unit := range group.Serialize() {
+		if unit.Name == "celsius" &&
+			(unit.Symbol != "C" || len(unit.Aliases) != 0) {
+			t.Errorf("unexpected serialized unit %+v", unit)
+		}
+	}
+}
+
+func TestUnitGroupSerializeFile(t *testing.T) {
+	group, err := NewUnitGroup(strings.NewReader(temperatureUnits))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registry := NewUnitRegistryFilesDefault()
+	registry.Add("temp", group)
+
+	data, err := json.Marshal(registry.Serialize()["temp"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := NewUnitGroup(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to read serialized group %s: %s", data, err)
+	}
+
+	base, _ := decoded.Base()
+
+	if decoded.Name() != "temperature" || base.Name != "kelvin" ||
+		decoded.Dimension() != "temperature" ||
+		decoded.CasePolicy() != CaseSensitive {
+		t.Errorf("group header lost in %s", data)
+	}
+
+	if celsius, ok := decoded.Get("C"); !ok || celsius.Offset != 273.15 {
+		t.Errorf("unit 'celsius' lost in %s", data)
+	}
+}
+
+func TestOffsetUnits(t *testing.T) {
+	group, err := NewUnitGroup(strings.NewReader(temperatureUnits))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	celsius, _ := group.Get("C")
+	kelvin, _ := group.Get("K")
+
+	if _, err := group.ParseQuantity("1 K, 2 C"); !errors.Is(
+		err,
+		ErrOffsetUnit,
+	) {
+		t.Errorf("ParseQuantity() error = %v, want ErrOffsetUnit", err)
+	}
+
+	quantity, err := group.ParseQuantity("20 C")
+	if err != nil || quantity.Base() != 293.15 {
+		t.Errorf("ParseQuantity() = %v (%v), want 293.15 K", quantity, err)
+	}
+
+	_, err = NewQuantity(20, celsius).Add(NewQuantity(1, kelvin))
+	if !errors.Is(err, ErrOffsetUnit) {
+		t.Errorf("Add() error = %v, want ErrOffsetUnit", err)
+	}
+
+	for _, unit := range group.UnitsUpTo(1000) {
+		if unit.HasOffset() {
+			t.Errorf("UnitsUpTo() includes '%s'", unit.Name)
+		}
+	}
+
+	scanned := SQLQuantity{Quantity: Quantity{Unit: celsius}}
+	if err := scanned.Scan(373.15); err != nil {
+		t.Fatal(err)
+	}
+
+	if math.Abs(scanned.Quantity.Value-100) > 1e-9 {
+		t.Errorf("Scan(373.15) = %v, want 100 celsius", scanned.Quantity)
+	}
+}
+
+func TestPrefixableUnits(t *testing.T) {
+	group, err := NewUnitGroup(strings.NewReader(`{
+		"version": 2,
+		"units": [
+			{
+				"name": "gram",
+				"value": 1,
+				"symbol": "g",
+				"plural": "grams",
+				"prefixable": true
+			},
+			{"name": "pound", "value": 453.59237, "symbol": "lb"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		alias      string
+		name       string
+		multiplier string
+	}{
+		{alias: "kg", name: "kilogram", multiplier: "1000"},
+		{alias: "kilograms", name: "kilogram", multiplier: "1000"},
+		{alias: "mg", name: "milligram", multiplier: "1/1000"},
+		{alias: "μg", name: "microgram", multiplier: "1/1000000"},
+		{alias: "dag", name: "decagram", multiplier: "10"},
+		{alias: "klb"},
+		{alias: "kgram"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.alias, func(t *testing.T) {
+			unit, ok := group.Get(tc.alias)
+
+			if len(tc.name) == 0 {
+				if ok {
+					t.Errorf("Get() = '%s', want none", unit.Name)
+				}
+
+				return
+			}
+
+			if !ok || unit.Name != tc.name ||
+				unit.ExactMultiplier().RatString() != tc.multiplier {
+				t.Fatalf("Get() = %+v, want %s", unit, tc.name)
+			}
+
+			if unit.Group() != group {
+				t.Errorf("prefixed unit does not belong to the group")
+			}
+		})
+	}
+
+	if group.Length() != 2 {
+		t.Errorf("prefixed units were added to the group")
+	}
+
+	registry := NewUnitRegistryFilesDefault()
+	registry.Add("mass", group)
+
+	if unit, ok := NewIndexedRegistry(&registry).FindUnit("kg"); !ok ||
+		unit.Name != "kilogram" {
+		t.Errorf("IndexedRegistry.FindUnit('kg') = %v, %v", unit, ok)
+	}
+}
+
+func TestNewUnitGroupInvalidBase(t *testing.T) {
+	_, err := NewUnitGroup(strings.NewReader(`{
+		"version": 2,
+		"group": {"base": "kilometer"},
+		"units": [{"name": "kilometer", "value": 1000}]
+	}`))
+
+	wantErr := "base unit 'kilometer' must have value 1, not 1000"
+	if err == nil || !strings.Contains(err.Error(), wantErr) {
+		t.Errorf("error = %v, want %q", err, wantErr)
+	}
+}
+
+func TestUnitFileSchema(t *testing.T) {
+	var schema struct {
+		Defs map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"$defs"`
+	}
+
+	if err := json.Unmarshal(UnitFileSchema, &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %s", err)
+	}
+
+	for def, value := range map[string]any{
+		"unit":  unit_entry.UnitEntry{},
+		"group": unit_entry.GroupEntry{},
+	} {
+		fields := reflect.TypeOf(value)
+
+		for i := range fields.NumField() {
+			tag, _, _ := strings.Cut(fields.Field(i).Tag.Get("json"), ",")
+
+			if _, ok := schema.Defs[def].Properties[tag]; !ok {
+				t.Errorf("schema of %s is missing property '%s'", def, tag)
+			}
+		}
+
+		if len(schema.Defs[def].Properties) != fields.NumField() {
+			t.Errorf("schema of %s has properties not read by Go", def)
+		}
+	}
+}
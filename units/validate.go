@@ -0,0 +1,259 @@
+package units
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Severity tells whether an Issue breaks lookups or only deserves a look.
+type Severity int
+
+const (
+	SeverityWarning Severity = iota
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+
+	return "warning"
+}
+
+// IssueKind identifies the check that produced an Issue.
+type IssueKind int
+
+const (
+	IssueDuplicateAlias      IssueKind = iota // alias overwritten in a group
+	IssueCrossGroupAlias                      // alias defined by many groups
+	IssueDuplicateMultiplier                  // units with the same value
+	IssueMissingBase                          // no unit with multiplier 1
+	IssueNonNormalizedName                    // name not in canonical form
+	IssuePrefixShadow                         // alias hides an SI prefix
+)
+
+// Issue is a single finding of Validate.
+type Issue struct {
+	Kind     IssueKind
+	Severity Severity
+	Group    string
+	Message  string
+}
+
+func (i Issue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Group, i.Message)
+}
+
+// ValidationReport lists the issues of a registry ordered by group.
+type ValidationReport struct {
+	Issues []Issue
+}
+
+// HasErrors reports whether any issue has SeverityError.
+func (r *ValidationReport) HasErrors() bool {
+	return slices.ContainsFunc(r.Issues, func(issue Issue) bool {
+		return issue.Severity == SeverityError
+	})
+}
+
+func (r *ValidationReport) add(
+	kind IssueKind,
+	severity Severity,
+	group string,
+	format string,
+	args ...any,
+) {
+	r.Issues = append(r.Issues, Issue{
+		Kind:     kind,
+		Severity: severity,
+		Group:    group,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+type siPrefix struct {
+	symbol string
+	name   string
+	factor float64
+}
+
+var siPrefixes = []siPrefix{
+	{"Q", "quetta", 1e30},
+	{"R", "ronna", 1e27},
+	{"Y", "yotta", 1e24},
+	{"Z", "zetta", 1e21},
+	{"E", "exa", 1e18},
+	{"P", "peta", 1e15},
+	{"T", "tera", 1e12},
+	{"G", "giga", 1e9},
+	{"M", "mega", 1e6},
+	{"k", "kilo", 1e3},
+	{"h", "hecto", 1e2},
+	{"da", "deca", 1e1},
+	{"d", "deci", 1e-1},
+	{"c", "centi", 1e-2},
+	{"m", "milli", 1e-3},
+	{"µ", "micro", 1e-6},
+	{"μ", "micro", 1e-6},
+	{"u", "micro", 1e-6},
+	{"n", "nano", 1e-9},
+	{"p", "pico", 1e-12},
+	{"f", "femto", 1e-15},
+	{"a", "atto", 1e-18},
+	{"z", "zepto", 1e-21},
+	{"y", "yocto", 1e-24},
+	{"r", "ronto", 1e-27},
+	{"q", "quecto", 1e-30},
+}
+
+func sameMultiplier(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(math.Abs(a), math.Abs(b))
+}
+
+func isNormalizedName(name string) bool {
+	return len(name) > 0 &&
+		name == NormalizeAlias(name) &&
+		name == strings.ToLower(name) &&
+		!strings.ContainsFunc(name, unicode.IsSpace)
+}
+
+// Validate checks every group of registry for aliases overwritten within a
+// group or shared between groups, units with equal multipliers, a missing
+// base unit, names that are not lowercase single words and aliases shadowing
+// an SI-prefixed form of another unit.
+func Validate(registry UnitRegistry) *ValidationReport {
+	report := &ValidationReport{}
+	owners := make(map[string][]string)
+
+	for key, group := range registry.Groups() {
+		group.validate(key, report)
+
+		for _, alias := range slices.Sorted(maps.Keys(group.aliases)) {
+			owners[alias] = append(owners[alias], key)
+		}
+	}
+
+	for _, alias := range slices.Sorted(maps.Keys(owners)) {
+		if groups := owners[alias]; len(groups) > 1 {
+			report.add(
+				IssueCrossGroupAlias,
+				SeverityWarning,
+				strings.Join(groups, ", "),
+				"alias '%s' is defined in groups %s",
+				alias,
+				strings.Join(groups, ", "),
+			)
+		}
+	}
+
+	return report
+}
+
+func (g *UnitGroup) validate(key string, report *ValidationReport) {
+	for _, shadowed := range g.shadowed {
+		report.add(
+			IssueDuplicateAlias,
+			SeverityError,
+			key,
+			"alias '%s' of unit '%s' is overwritten by unit '%s'",
+			shadowed.alias,
+			shadowed.previous.Name,
+			shadowed.unit.Name,
+		)
+	}
+
+	if _, ok := g.Base(); !ok {
+		report.add(
+			IssueMissingBase,
+			SeverityError,
+			key,
+			"no base unit with multiplier 1",
+		)
+	}
+
+	for i, unit := range g.units {
+		if !isNormalizedName(unit.Name) {
+			report.add(
+				IssueNonNormalizedName,
+				SeverityWarning,
+				key,
+				"unit name '%s' is not a lowercase NFKC single word",
+				unit.Name,
+			)
+		}
+
+		if i > 0 && sameMultiplier(g.units[i-1].Multiplier, unit.Multiplier) {
+			report.add(
+				IssueDuplicateMultiplier,
+				SeverityWarning,
+				key,
+				"units '%s' and '%s' share multiplier %g",
+				g.units[i-1].Name,
+				unit.Name,
+				unit.Multiplier,
+			)
+		}
+	}
+
+	for _, alias := range slices.Sorted(maps.Keys(g.aliases)) {
+		g.validatePrefix(key, alias, report)
+	}
+}
+
+// validatePrefix reports alias when it reads as an SI prefix applied to
+// another alias of the group but does not carry the prefixed multiplier, as
+// "nmi" for nautical miles reads as nano-miles.
+func (g *UnitGroup) validatePrefix(
+	key, alias string,
+	report *ValidationReport,
+) {
+	unit := g.aliases[alias]
+
+	heads := func(prefix siPrefix) []string {
+		// a unit name such as "day" is a word, not deca-y
+		if alias == unit.Name {
+			return []string{prefix.name}
+		}
+
+		return []string{prefix.symbol, prefix.name}
+	}
+
+	for _, prefix := range siPrefixes {
+		for _, head := range heads(prefix) {
+			rest, ok := strings.CutPrefix(alias, head)
+			if !ok || len(rest) == 0 {
+				continue
+			}
+
+			base, ok := g.aliases[rest]
+			if !ok {
+				continue
+			}
+
+			expected := prefix.factor * base.Multiplier
+			if sameMultiplier(expected, unit.Multiplier) {
+				continue
+			}
+
+			report.add(
+				IssuePrefixShadow,
+				SeverityWarning,
+				key,
+				"alias '%s' of unit '%s' reads as %s-'%s' (%g × '%s')",
+				alias,
+				unit.Name,
+				prefix.name,
+				rest,
+				prefix.factor,
+				base.Name,
+			)
+
+			return
+		}
+	}
+}
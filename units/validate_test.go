@@ -0,0 +1,108 @@
+package units
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+const validateLength = `[
+	{"name": "meter", "value": 1, "aliases": ["m"]},
+	{"name": "Kilometer", "value": 1000, "aliases": ["km"]},
+	{"name": "klick", "value": 1000, "aliases": ["km"]},
+	{"name": "nauticalmile", "value": 1852, "aliases": ["nm"]}
+]`
+
+const validateTime = `[
+	{"name": "minute", "value": 60, "aliases": ["m", "min"]}
+]`
+
+func TestValidate(t *testing.T) {
+	registry, err := NewUnitRegistryFiles(fstest.MapFS{
+		"units/length.json": {Data: []byte(validateLength)},
+		"units/time.json":   {Data: []byte(validateTime)},
+	}, "units")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report := Validate(&registry)
+
+	if !report.HasErrors() {
+		t.Error("HasErrors() = false, want true")
+	}
+
+	expected := []struct {
+		kind     IssueKind
+		severity Severity
+		group    string
+		message  string
+	}{
+		{
+			IssueDuplicateAlias,
+			SeverityError,
+			"length",
+			"alias 'km' of unit 'Kilometer' is overwritten by unit 'klick'",
+		},
+		{
+			IssueNonNormalizedName,
+			SeverityWarning,
+			"length",
+			"unit name 'Kilometer' is not a lowercase NFKC single word",
+		},
+		{
+			IssueDuplicateMultiplier,
+			SeverityWarning,
+			"length",
+			"units 'Kilometer' and 'klick' share multiplier 1000",
+		},
+		{
+			IssuePrefixShadow,
+			SeverityWarning,
+			"length",
+			"alias 'nm' of unit 'nauticalmile' reads as nano-'m'",
+		},
+		{
+			IssueMissingBase,
+			SeverityError,
+			"time",
+			"no base unit with multiplier 1",
+		},
+		{
+			IssueCrossGroupAlias,
+			SeverityWarning,
+			"length, time",
+			"alias 'm' is defined in groups length, time",
+		},
+	}
+
+	if len(report.Issues) != len(expected) {
+		t.Fatalf("expected %d issues, got %v", len(expected), report.Issues)
+	}
+
+	for i, exp := range expected {
+		issue := report.Issues[i]
+
+		if issue.Kind != exp.kind ||
+			issue.Severity != exp.severity ||
+			issue.Group != exp.group ||
+			!strings.HasPrefix(issue.Message, exp.message) {
+			t.Errorf("issue %d = %v, want %s", i, issue, exp.message)
+		}
+	}
+}
+
+func TestValidateEmbedded(t *testing.T) {
+	report := Validate(EmbeddedUnitRegistry)
+
+	if report.HasErrors() {
+		t.Errorf("embedded units have errors: %v", report.Issues)
+	}
+
+	if !slices.ContainsFunc(report.Issues, func(issue Issue) bool {
+		return issue.Kind == IssueCrossGroupAlias
+	}) {
+		t.Error("expected the shared alias 'm' to be reported")
+	}
+}